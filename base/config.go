@@ -1,14 +1,31 @@
 package base
 
 import (
+	"context"
 	"crypto/rsa"
 	"time"
 
+	"github.com/go-gorote/auth/manifest"
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/notify"
+	"github.com/go-gorote/auth/passwd"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/rebac"
+	"github.com/go-gorote/auth/sod"
 	"github.com/go-gorote/gorote/storage"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
+// LoginIdentifier is a kind of identifier users log in with.
+type LoginIdentifier string
+
+const (
+	LoginEmail    LoginIdentifier = "email"
+	LoginUsername LoginIdentifier = "username"
+	LoginPhone    LoginIdentifier = "phone"
+)
+
 type Config struct {
 	*fiber.App
 	*gorm.DB
@@ -23,4 +40,88 @@ type Config struct {
 	Domain           string
 	Storage          storage.StorageProvider
 	Bucket           string
+	Namespaces       []rebac.Namespace
+	AuthzCacheTTL    time.Duration
+	PolicyFile       string
+	PolicySyncMode   manifest.Mode
+	Permissions      permission.Registry
+	// AssignmentSweepInterval is how often expired role and tenant
+	// assignments are removed. The sweeper only runs when it is positive;
+	// expired assignments grant nothing either way, they are just kept.
+	AssignmentSweepInterval time.Duration
+	// Context bounds the background work started by auth.New, such as the
+	// assignment sweeper, which stops when it is done. Defaults to
+	// context.Background, which runs it for the life of the process.
+	Context context.Context
+	// Notifier delivers account notifications. Messages are only logged
+	// when it is nil.
+	Notifier notify.Notifier
+	// AccessApproverPermission is the code that allows reviewing access
+	// requests. Defaults to approve_access_request.
+	AccessApproverPermission permission.PermissionCode
+	// SeparationOfDuties lists the sets of roles no user may hold together.
+	SeparationOfDuties []sod.Rule
+	// RecentAuthMaxAge is how long after authenticating users may change
+	// their own password or email without confirming the current password.
+	// Defaults to 5 minutes.
+	RecentAuthMaxAge time.Duration
+	// MFAVerifier checks a second factor code for step-up authentication.
+	// Reauthentication only accepts codes when it is set.
+	MFAVerifier func(ctx context.Context, userID, code string) error
+	// PasswordPolicy applies to every password set through the module.
+	// Defaults to passwd.DefaultPolicy when left zero.
+	PasswordPolicy passwd.Policy
+	// PasswordHasher hashes new passwords. Defaults to passwd.DefaultHasher.
+	// Stored hashes of other algorithms or parameters keep working and are
+	// upgraded at the next successful login.
+	PasswordHasher passwd.PasswordHasher
+	// TenantPasswordPolicies replace PasswordPolicy for the users of the
+	// tenants named by the keys. Users of several such tenants follow the
+	// strictest combination of their policies.
+	TenantPasswordPolicies map[string]passwd.Policy
+	// BreachedPasswordsFile points to a corpus of breached passwords new
+	// passwords are checked against, either a sorted SHA-1 hash file such
+	// as the one of Have I Been Pwned or a bloom filter written by
+	// passwd.BloomFilter. See passwd.OpenCorpus.
+	BreachedPasswordsFile string
+	// EmailChangeTTL is how long the link confirming a new email address
+	// stays valid. Defaults to 24 hours.
+	EmailChangeTTL time.Duration
+	// InvitationTTL is how long invitations stay valid unless they set
+	// their own expiry. Defaults to 7 days.
+	InvitationTTL time.Duration
+	// InvitationURL is the page of the application accepting invitations.
+	// When set, the link sent to invitees is this URL with the token in its
+	// "token" query parameter.
+	InvitationURL string
+	// SelfRegistration enables POST /auth/register, where anyone may create
+	// an account. It is off by default.
+	SelfRegistration bool
+	// RegistrationRoles and RegistrationTenants name the roles and tenants
+	// given to self-registered users.
+	RegistrationRoles   []string
+	RegistrationTenants []string
+	// RegistrationEmailDomains limits self-registration to emails of these
+	// domains. Any domain may register when it is empty.
+	RegistrationEmailDomains []string
+	// CaptchaVerifier checks the CAPTCHA response sent with a registration.
+	// Registrations need none when it is nil.
+	CaptchaVerifier func(ctx context.Context, response, remoteIP string) error
+	// RegistrationVerification creates self-registered users inactive and
+	// sends them a link activating their account once followed.
+	RegistrationVerification bool
+	// RegistrationTTL is how long the link verifying a registration stays
+	// valid. Defaults to 24 hours.
+	RegistrationTTL time.Duration
+	// OnRegister is called with each self-registered user before it is
+	// saved. Returning an error rejects the registration with it.
+	OnRegister func(ctx context.Context, user *model.User) error
+	// LoginIdentifiers are the kinds of identifier users may log in with.
+	// Defaults to email and username. Phones aren't unique, so a phone
+	// only identifies the user when no one else has it as Phone1.
+	LoginIdentifiers []LoginIdentifier
+	// AccountStatusCacheTTL is how long protected routes trust the account
+	// status they looked up, so a suspension takes up to this long to
+	// reach other instances of the application. Defaults to 30 seconds.
+	AccountStatusCacheTTL time.Duration
 }
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-gorote/auth/manifest"
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/passwd"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/secret"
+	"gorm.io/gorm"
+)
+
+// RunCommand runs a maintenance command against the database, so that an
+// application can expose it from its own binary, e.g.
+//
+//	if len(os.Args) > 1 {
+//		if err := auth.RunCommand(db, os.Args[1:], os.Stdout); err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+//
+// Commands:
+//
+//	sync-policy -file roles.yaml [-mode create|update|prune] [-dry-run] [-json]
+//	break-glass -user admin@example.com [-password secret [-breached-passwords file] | -keep-password]
+//	normalize-identities [-dry-run] [-json]
+//
+// break-glass restores administrative access from the server side, e.g.
+// after the last super user was locked out or the admin permission was
+// deactivated directly in the database. It activates the user whatever its
+// status, even deprovisioned, makes it a super user, reactivates the admin
+// permission and resets the password, printing a generated one unless
+// -password or -keep-password is given. A generated password must be
+// changed at the next login. A chosen one is checked against
+// passwd.DefaultPolicy and, with -breached-passwords, against a breached
+// password corpus (see passwd.OpenCorpus).
+// Existing refresh tokens of the user are revoked and every run is stored
+// as an audit event. Run it only from a trusted shell on the server.
+//
+// normalize-identities reports the users whose emails or usernames collide
+// once normalized, which Migrate refuses to proceed with, and otherwise
+// normalizes them. See NormalizeIdentities.
+func RunCommand(db *gorm.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command")
+	}
+	switch args[0] {
+	case "sync-policy":
+		return runSyncPolicy(db, args[1:], out)
+	case "break-glass":
+		return runBreakGlass(db, args[1:], out)
+	case "normalize-identities":
+		return runNormalizeIdentities(db, args[1:], out)
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func runSyncPolicy(db *gorm.DB, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("sync-policy", flag.ContinueOnError)
+	fs.SetOutput(out)
+	file := fs.String("file", "", "path to the YAML or JSON policy file")
+	mode := fs.String("mode", string(manifest.ModeCreate), "sync mode: create, update or prune")
+	dryRun := fs.Bool("dry-run", false, "print the changes without applying them")
+	asJSON := fs.Bool("json", false, "print the changes as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+	m, err := manifest.ParseMode(*mode)
+	if err != nil {
+		return err
+	}
+
+	diff, err := SyncPolicyFile(db, *file, m, *dryRun)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return json.NewEncoder(out).Encode(diff)
+	}
+	_, err = fmt.Fprint(out, diff.String())
+	return err
+}
+
+func runBreakGlass(db *gorm.DB, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("break-glass", flag.ContinueOnError)
+	fs.SetOutput(out)
+	identifier := fs.String("user", "", "email or username of the user to restore")
+	password := fs.String("password", "", "new password; a random one is generated when empty")
+	keepPassword := fs.Bool("keep-password", false, "keep the current password")
+	breachedFile := fs.String("breached-passwords", "", "breached password corpus -password is checked against")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *identifier == "" {
+		return fmt.Errorf("-user is required")
+	}
+	if *keepPassword && *password != "" {
+		return fmt.Errorf("-password and -keep-password can't be used together")
+	}
+
+	generated := false
+	if !*keepPassword && *password == "" {
+		p, err := secret.RandomPassword(20)
+		if err != nil {
+			return err
+		}
+		*password, generated = p, true
+	}
+	var breached passwd.Corpus
+	if *breachedFile != "" {
+		corpus, err := passwd.OpenCorpus(*breachedFile)
+		if err != nil {
+			return err
+		}
+		defer corpus.Close()
+		breached = corpus
+	}
+
+	if err := setupJoinTables(db); err != nil {
+		return err
+	}
+	if err := setPermissions(db, permission.Builtin); err != nil {
+		return err
+	}
+	var user model.User
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("email = ? OR username = ?", model.NormalizeEmail(*identifier), model.NormalizeUsername(*identifier)).First(&user).Error; err != nil {
+			return fmt.Errorf("user %s not found", *identifier)
+		}
+		// The command has no access to the application's configuration,
+		// so a chosen password is held to passwd.DefaultPolicy.
+		if !*keepPassword && !generated {
+			if err := passwd.DefaultPolicy.Validate(*password, passwd.UserInfo{
+				FirstName: user.FirstName,
+				LastName:  user.LastName,
+				Username:  user.Username,
+				Email:     user.Email,
+			}, breached); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		updates := map[string]any{
+			"is_super_user":     true,
+			"active":            true,
+			"status":            model.UserActive,
+			"suspended_reason":  "",
+			"suspended_until":   nil,
+			"status_changed_at": now,
+			"updated_at":        now,
+		}
+		if !*keepPassword {
+			hash, err := passwd.DefaultHasher.Hash(*password)
+			if err != nil {
+				return fmt.Errorf("failed to hash password")
+			}
+			updates["password"] = hash
+			updates["password_changed_at"] = now
+			// Only the operator has seen a generated password.
+			updates["must_change_password"] = generated
+		}
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to restore user: %w", err)
+		}
+		if err := tx.Model(&model.Permission{}).
+			Where("code = ?", string(permission.PermissionAdmin)).
+			Update("active", true).Error; err != nil {
+			return fmt.Errorf("failed to reactivate the admin permission: %w", err)
+		}
+		return tx.Create(&model.AuditEvent{
+			Action:     "break_glass",
+			TargetType: "user",
+			TargetID:   user.ID.String(),
+			Outcome:    model.AuditOutcomeSuccess,
+			Reason:     "super user access restored from the command line",
+			Details:    map[string]any{"password_reset": !*keepPassword, "previous_status": string(user.Status)},
+		}).Error
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "restored super user access for %s (%s)\n", user.Username, user.Email)
+	if generated {
+		fmt.Fprintf(out, "temporary password: %s\n", *password)
+	}
+	return nil
+}
+
+func runNormalizeIdentities(db *gorm.DB, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("normalize-identities", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dryRun := fs.Bool("dry-run", false, "print the report without normalizing")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := NormalizeIdentities(db, *dryRun)
+	if report != nil {
+		if *asJSON {
+			if err := json.NewEncoder(out).Encode(report); err != nil {
+				return err
+			}
+		} else if _, err := fmt.Fprint(out, report.String()); err != nil {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListAccessRequestsHandler godoc
+// @Summary      List access requests
+// @Description  Lists all access requests for approvers, and the caller's own requests for everyone else
+// @Tags         AccessRequest
+// @Produce      json
+// @Param        page query int false "Page number of access requests to retrieve"
+// @Param        limit query int false "Number of access requests to retrieve per page"
+// @Param        status query string false "Filter by status (pending, approved, denied)"
+// @Success      200 {object} dto.ListAccessRequestsDto "Access requests retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve access requests"
+// @Failure      404 {object} dto.ResponseError "No access requests found"
+// @Router       /access-requests [get]
+func (c *AppController) ListAccessRequestsHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ListAccessRequests)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	userID := claims.ID
+	if claims.HasPermission(permission.PermissionAdmin, c.Service.ApproverPermission()) {
+		userID = ""
+	}
+	requests, err := c.Service.AccessRequests(req, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(requests) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "no access requests found")
+	}
+	countRequests := uint(len(requests))
+	if err := gorote.Pagination(req.Page, req.Limit, &requests); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	var data []dto.AccessRequestDto
+	for _, request := range requests {
+		data = append(data, request.ToAccessRequestDto())
+	}
+	res := &dto.ListAccessRequestsDto{
+		Page:  req.Page,
+		Limit: req.Limit,
+		Total: countRequests,
+		Data:  data,
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// CreateAccessRequestHandler godoc
+// @Summary      Request access
+// @Description  Requests a role or a tenant for the caller, with a justification and an optional duration such as "8h"
+// @Tags         AccessRequest
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.CreateAccessRequest true "Access request data"
+// @Success      201 {object} dto.AccessRequestDto "Access request created successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to create access request"
+// @Router       /access-requests [post]
+func (c *AppController) CreateAccessRequestHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.CreateAccessRequest)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	res, err := c.Service.CreateAccessRequest(ctx.UserContext(), claims.ID, req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	c.Logger.InfoContext(ctx.UserContext(), "access requested", "user_id", claims.ID, "access_request_id", res.ID.String())
+	return ctx.Status(fiber.StatusCreated).JSON(res.ToAccessRequestDto())
+}
+
+// ApproveAccessRequestHandler godoc
+// @Summary      Approve an access request
+// @Description  Approves a pending access request and grants the role or tenant for the requested duration
+// @Tags         AccessRequest
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id access request"
+// @Param        req body schema.ReviewAccessRequest true "Review note, may be empty"
+// @Success      200 {object} dto.AccessRequestDto "Access request approved"
+// @Failure      400 {object} dto.ResponseError "Failed to approve access request"
+// @Failure      403 {object} dto.ResponseError "You don't have permission to review access requests"
+// @Router       /access-requests/{id}/approve [post]
+func (c *AppController) ApproveAccessRequestHandler(ctx *fiber.Ctx) error {
+	return c.reviewAccessRequest(ctx, true)
+}
+
+// DenyAccessRequestHandler godoc
+// @Summary      Deny an access request
+// @Description  Denies a pending access request
+// @Tags         AccessRequest
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id access request"
+// @Param        req body schema.ReviewAccessRequest true "Review note, may be empty"
+// @Success      200 {object} dto.AccessRequestDto "Access request denied"
+// @Failure      400 {object} dto.ResponseError "Failed to deny access request"
+// @Failure      403 {object} dto.ResponseError "You don't have permission to review access requests"
+// @Router       /access-requests/{id}/deny [post]
+func (c *AppController) DenyAccessRequestHandler(ctx *fiber.Ctx) error {
+	return c.reviewAccessRequest(ctx, false)
+}
+
+func (c *AppController) reviewAccessRequest(ctx *fiber.Ctx, approve bool) error {
+	req := ctx.Locals("validatedData").(*schema.ReviewAccessRequest)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	if !claims.HasPermission(permission.PermissionAdmin, c.Service.ApproverPermission()) {
+		return fiber.NewError(fiber.StatusForbidden, "you don't have permission to review access requests")
+	}
+	res, err := c.Service.ReviewAccessRequest(ctx.UserContext(), claims, req, approve)
+	if err != nil {
+		return grantError(err, "")
+	}
+	c.Logger.InfoContext(ctx.UserContext(), "access request reviewed",
+		"access_request_id", res.ID.String(),
+		"reviewer_id", claims.ID,
+		"status", res.Status,
+	)
+	return ctx.Status(fiber.StatusOK).JSON(res.ToAccessRequestDto())
+}
@@ -1,7 +1,11 @@
 package controller
 
 import (
+	"cmp"
+	"time"
+
 	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/model"
 	"github.com/go-gorote/auth/schema"
 	"github.com/go-gorote/auth/secret"
 	"github.com/go-gorote/gorote"
@@ -10,13 +14,16 @@ import (
 
 // Login godoc
 // @Summary      User login
-// @Description  Authenticate user with email and password
+// @Description  Authenticate user with password and email, username or E.164 phone, as allowed by the configuration
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
-// @Param        credentials body schema.Login true "User login credentials (email and password required)"
-// @Success      200 {object} dto.Token "Login successful - returns access_token and refresh_token"
-// @Failure      400 {object} dto.ResponseError "Bad request - validation error, invalid body, invalid credentials, or user inactive"
+// @Param        credentials body schema.Login true "User login credentials (identifier, or email for older clients, and password required)"
+// @Success      200 {object} dto.Token "Login successful - returns access_token and refresh_token, or only a token allowing PUT /users/password/{id} when must_change_password is set"
+// @Failure      400 {object} dto.ResponseError "Bad request - validation error, invalid body or invalid credentials"
+// @Failure      403 {object} dto.ResponseError "Password expired - change it through /auth/password/expired - or account pending or suspended"
+// @Failure      410 {object} dto.ResponseError "Account deprovisioned"
+// @Failure      423 {object} dto.ResponseError "Account locked"
 // @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
 // @Router       /auth/login [post]
 func (c *AppController) LoginHandler(ctx *fiber.Ctx) error {
@@ -27,7 +34,7 @@ func (c *AppController) LoginHandler(ctx *fiber.Ctx) error {
 	}
 
 	c.Logger.InfoContext(ctx.UserContext(), "login attempt",
-		"email", req.Email,
+		"identifier", cmp.Or(req.Identifier, req.Email),
 		"Host", ctx.Get("Host"),
 		"Origin", ctx.Get("Origin"),
 		"Content-Type", ctx.Get("Content-Type"),
@@ -36,7 +43,11 @@ func (c *AppController) LoginHandler(ctx *fiber.Ctx) error {
 	user, err := c.Service.Login(req)
 	if err != nil {
 		c.Logger.ErrorContext(ctx.UserContext(), "login failed", "error", err)
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return loginError(err)
+	}
+
+	if user.MustChangePassword {
+		return c.passwordChangeLogin(ctx, user)
 	}
 
 	accessToken, err := c.Service.GenerateJwt(user, "access_token")
@@ -69,6 +80,30 @@ func (c *AppController) LoginHandler(ctx *fiber.Ctx) error {
 	})
 }
 
+// passwordChangeLogin answers the login of a user who must change their
+// password with a token allowing only that.
+func (c *AppController) passwordChangeLogin(ctx *fiber.Ctx, user *model.User) error {
+	token, err := c.Service.GenerateJwt(user, "password_change_token")
+	if err != nil {
+		c.Logger.ErrorContext(ctx.UserContext(), "failed to generate password change token", "error", err)
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := c.Service.SetCookie(ctx, "access_token", token); err != nil {
+		c.Logger.ErrorContext(ctx.UserContext(), "failed to set access token cookie", "error", err)
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := c.Service.DeleteCookie(ctx, "refresh_token"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	c.Logger.InfoContext(ctx.UserContext(), "logged in to change password", "user_id", user.ID.String())
+
+	return ctx.Status(fiber.StatusOK).JSON(dto.Token{
+		AccessToken:        token,
+		MustChangePassword: true,
+	})
+}
+
 // Logout godoc
 // @Summary      Logout user
 // @Description  Delete access token and refresh token cookies to log out user
@@ -93,7 +128,10 @@ func (c *AppController) LogoutHandler(ctx *fiber.Ctx) error {
 // @Produce      json
 // @Param        refresh_token body schema.RefreshToken true "Refresh token data (optional if sent as cookie)"
 // @Success      200 {object} dto.Token "Token refreshed successfully - returns new access_token and same refresh_token"
-// @Failure      400 {object} dto.ResponseError "Bad request - validation error, invalid body, user not found, or user inactive"
+// @Failure      400 {object} dto.ResponseError "Bad request - validation error, invalid body or user not found"
+// @Failure      403 {object} dto.ResponseError "Account pending or suspended, or password must be changed"
+// @Failure      410 {object} dto.ResponseError "Account deprovisioned"
+// @Failure      423 {object} dto.ResponseError "Account locked"
 // @Failure      401 {object} dto.ResponseError "Unauthorized - invalid or expired refresh token"
 // @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
 // @Router       /auth/refresh [post]
@@ -108,6 +146,10 @@ func (c *AppController) RefreshTokenHandler(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
+	if claims.Type != "refresh_token" {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is not a refresh token")
+	}
+
 	c.Logger.InfoContext(ctx.UserContext(), "refresh attempt", "user_id", claims.ID)
 
 	users, err := c.Service.Users(claims.ID)
@@ -120,9 +162,13 @@ func (c *AppController) RefreshTokenHandler(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "id user not found")
 	}
 	user := users[0]
-	if !user.Active {
-		c.Logger.ErrorContext(ctx.UserContext(), "failed to refrash token: user inactive", "user_id", user.ID.String())
-		return fiber.NewError(fiber.StatusBadRequest, "failed to refrash token: user is inactive")
+	if err := user.AccountError(time.Now()); err != nil {
+		c.Logger.ErrorContext(ctx.UserContext(), "failed to refrash token: user not active", "user_id", user.ID.String(), "status", user.Status)
+		return fiber.NewError(secret.AccountStatus(err), "failed to refrash token: "+err.Error())
+	}
+
+	if user.MustChangePassword {
+		return fiber.NewError(fiber.StatusForbidden, "failed to refrash token: password must be changed")
 	}
 
 	if user.UpdatedAt.Unix() > claims.IssuedAt.Unix() {
@@ -130,7 +176,11 @@ func (c *AppController) RefreshTokenHandler(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "failed to refrash token: user is inactive")
 	}
 
-	accessToken, err := c.Service.GenerateJwt(&user, "access_token")
+	authTime := claims.IssuedAt.Time
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
+	}
+	accessToken, err := c.Service.GenerateJwtAuthenticatedAt(&user, "access_token", authTime)
 	if err != nil {
 		c.Logger.ErrorContext(ctx.UserContext(), "failed to generate access token", "error", err)
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
@@ -198,3 +248,45 @@ func (c *AppController) SetCookiePainelAdminHandler(ctx *fiber.Ctx) error {
 	return ctx.Next()
 }
 
+// Reauthenticate godoc
+// @Summary      Reauthenticate
+// @Description  Confirms the password, or a second factor code, of the authenticated user and issues new tokens with a fresh auth_time for routes that require a recent authentication
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.Reauthenticate true "Password or second factor code"
+// @Success      200 {object} dto.Token "Reauthenticated - returns new access_token and refresh_token"
+// @Failure      400 {object} dto.ResponseError "Bad request - invalid password or code"
+// @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
+// @Router       /auth/reauthenticate [post]
+func (c *AppController) ReauthenticateHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.Reauthenticate)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	user, err := c.Service.Reauthenticate(ctx.UserContext(), claims.ID, req)
+	if err != nil {
+		c.Logger.ErrorContext(ctx.UserContext(), "reauthentication failed", "error", err, "user_id", claims.ID)
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	accessToken, err := c.Service.GenerateJwt(user, "access_token")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := c.Service.SetCookie(ctx, "access_token", accessToken); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	refreshToken, err := c.Service.GenerateJwt(user, "refresh_token")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := c.Service.SetCookie(ctx, "refresh_token", refreshToken); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	c.Logger.InfoContext(ctx.UserContext(), "reauthenticated", "user_id", user.ID.String())
+
+	return ctx.Status(fiber.StatusOK).JSON(dto.Token{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
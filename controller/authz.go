@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CheckAccessHandler godoc
+// @Summary      Check access
+// @Description  Evaluates permission codes and an optional tenant for a token or a subject with the same rules as the route middlewares. Without token and subject the caller's own token is evaluated; checking another subject requires the check_authz permission.
+// @Tags         Authorization
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.AuthzCheck true "Check data"
+// @Success      200 {object} dto.AuthzDecisionDto "Decision evaluated successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to evaluate decision"
+// @Failure      401 {object} dto.ResponseError "Missing or invalid caller token"
+// @Failure      403 {object} dto.ResponseError "Caller can't check other subjects"
+// @Router       /authz/check [post]
+func (c *AppController) CheckAccessHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.AuthzCheck)
+	res, err := c.checkAccess(ctx, *req)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// CheckAccessBatchHandler godoc
+// @Summary      Check access in batch
+// @Description  Evaluates several access checks in one request, each with the rules of /authz/check
+// @Tags         Authorization
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.AuthzCheckBatch true "Checks data"
+// @Success      200 {object} dto.AuthzBatchDto "Decisions evaluated successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to evaluate decisions"
+// @Failure      401 {object} dto.ResponseError "Missing or invalid caller token"
+// @Failure      403 {object} dto.ResponseError "Caller can't check other subjects"
+// @Router       /authz/check/batch [post]
+func (c *AppController) CheckAccessBatchHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.AuthzCheckBatch)
+	res := dto.AuthzBatchDto{Results: []dto.AuthzDecisionDto{}}
+	for _, check := range req.Checks {
+		decision, err := c.checkAccess(ctx, check)
+		if err != nil {
+			return err
+		}
+		res.Results = append(res.Results, *decision)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+func (c *AppController) checkAccess(ctx *fiber.Ctx, req schema.AuthzCheck) (*dto.AuthzDecisionDto, error) {
+	if req.Token == "" {
+		caller := gorote.GetAccessToken(ctx)
+		if caller == "" {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "token or caller token is required")
+		}
+		if req.SubjectID == "" {
+			req.Token = caller
+		} else {
+			var claims secret.JwtClaims
+			if err := c.Service.Claims(&claims, caller); err != nil {
+				return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+			}
+			if claims.Type == "refresh_token" {
+				return nil, fiber.NewError(fiber.StatusUnauthorized, "token is refresh token")
+			}
+			if claims.ID != req.SubjectID && !claims.HasPermission(permission.PermissionCheckAuthz) {
+				return nil, fiber.NewError(fiber.StatusForbidden, "you don't have permission to check other subjects")
+			}
+		}
+	}
+
+	decision, err := c.Service.CheckAccess(&req)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	permissions := req.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return &dto.AuthzDecisionDto{
+		SubjectID:   req.SubjectID,
+		Permissions: permissions,
+		Tenant:      req.Tenant,
+		Allowed:     decision.Allowed,
+		Reason:      decision.Reason,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/auth/service"
+	"github.com/go-gorote/auth/sod"
+	"github.com/gofiber/fiber/v2"
+)
+
+// grantError maps the errors of operations that grant access to a status.
+// Other errors become a 400 with msg, or with the error itself when msg is
+// empty.
+func grantError(err error, msg string) error {
+	switch {
+	case errors.Is(err, service.ErrEscalation):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, sod.ErrConflict), errors.Is(err, service.ErrLockout), errors.Is(err, service.ErrInvalidTransition):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	if msg == "" {
+		msg = err.Error()
+	}
+	return fiber.NewError(fiber.StatusBadRequest, msg)
+}
+
+// loginError maps the errors of authentication to a status. An expired
+// password is a 403, so that clients can ask for a new one, and accounts
+// that aren't active get the status of secret.AccountStatus.
+func loginError(err error) error {
+	if errors.Is(err, service.ErrPasswordExpired) {
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	if accountError(err) {
+		return fiber.NewError(secret.AccountStatus(err), err.Error())
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
+
+func accountError(err error) bool {
+	return errors.Is(err, secret.ErrAccountPending) ||
+		errors.Is(err, secret.ErrAccountSuspended) ||
+		errors.Is(err, secret.ErrAccountLocked) ||
+		errors.Is(err, secret.ErrAccountDeprovisioned)
+}
+
+// stepUpError maps a failed step-up check to a 401, so that clients know to
+// reauthenticate.
+func stepUpError(err error) error {
+	if errors.Is(err, service.ErrRecentAuthRequired) {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+	return fiber.NewError(fiber.StatusBadRequest, err.Error())
+}
+
+// registerError maps the errors of self-registration to a status. Disabled
+// registration is a 404, as if the route didn't exist.
+func registerError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrRegistrationDisabled):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrEmailDomain):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	return grantError(err, "")
+}
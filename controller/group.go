@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListGroupsHandler godoc
+// @Summary      List all groups
+// @Description  Lists all groups with their members, roles and tenants
+// @Tags         Group
+// @Produce      json
+// @Param        page query int false "Page number of groups to retrieve"
+// @Param        limit query int false "Number of groups to retrieve per page"
+// @Success      200 {object} dto.ListGroupsDto "Groups retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve groups"
+// @Failure      404 {object} dto.ResponseError "No groups found"
+// @Router       /groups [get]
+func (c *AppController) ListGroupsHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.Paginate)
+	groups, err := c.Service.Groups()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(groups) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "no groups found")
+	}
+	countGroups := uint(len(groups))
+	if err := gorote.Pagination(req.Page, req.Limit, &groups); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	var data []dto.GroupDto
+	for _, group := range groups {
+		data = append(data, group.ToGroupDto())
+	}
+	res := &dto.ListGroupsDto{
+		Page:  req.Page,
+		Limit: req.Limit,
+		Total: countGroups,
+		Data:  data,
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// CreateGroupHandler godoc
+// @Summary      Create a group
+// @Description  Creates a group with the roles and tenants its members inherit
+// @Tags         Group
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.CreateGroup true "Group data"
+// @Success      201 {object} dto.GroupDto "Group created successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to create group"
+// @Failure      403 {object} dto.ResponseError "Grants roles or tenants beyond the editor's access"
+// @Router       /groups [post]
+func (c *AppController) CreateGroupHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.CreateGroup)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	group, err := c.Service.CreateGroup(req, claims)
+	if err != nil {
+		return grantError(err, "")
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(group.ToGroupDto())
+}
+
+// UpdateGroupHandler godoc
+// @Summary      Update a group
+// @Description  Updates a group with new data. Members keep their membership
+// @Tags         Group
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id group"
+// @Param        req body schema.UpdateGroup true "Group data"
+// @Success      200 {object} dto.GroupDto "Group updated successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to update group"
+// @Failure      403 {object} dto.ResponseError "Grants roles or tenants beyond the editor's access"
+// @Failure      409 {object} dto.ResponseError "Roles break a separation-of-duties rule for a member"
+// @Router       /groups/{id} [put]
+func (c *AppController) UpdateGroupHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.UpdateGroup)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	group, err := c.Service.UpdateGroup(req, claims)
+	if err != nil {
+		return grantError(err, "")
+	}
+	return ctx.Status(fiber.StatusOK).JSON(group.ToGroupDto())
+}
+
+// AddGroupMembersHandler godoc
+// @Summary      Add group members
+// @Description  Adds users to a group
+// @Tags         Group
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id group"
+// @Param        req body schema.GroupMembers true "User ids"
+// @Success      200 {object} dto.GroupDto "Members added successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to add members"
+// @Failure      403 {object} dto.ResponseError "The group grants roles or tenants beyond the editor's access"
+// @Failure      409 {object} dto.ResponseError "Membership breaks a separation-of-duties rule"
+// @Router       /groups/{id}/members [post]
+func (c *AppController) AddGroupMembersHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.GroupMembers)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	group, err := c.Service.AddGroupMembers(req, claims)
+	if err != nil {
+		return grantError(err, "")
+	}
+	return ctx.Status(fiber.StatusOK).JSON(group.ToGroupDto())
+}
+
+// RemoveGroupMembersHandler godoc
+// @Summary      Remove group members
+// @Description  Removes users from a group and revokes their refresh tokens
+// @Tags         Group
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id group"
+// @Param        req body schema.GroupMembers true "User ids"
+// @Success      200 {object} dto.GroupDto "Members removed successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to remove members"
+// @Router       /groups/{id}/members [delete]
+func (c *AppController) RemoveGroupMembersHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.GroupMembers)
+	group, err := c.Service.RemoveGroupMembers(req)
+	if err != nil {
+		return grantError(err, "")
+	}
+	return ctx.Status(fiber.StatusOK).JSON(group.ToGroupDto())
+}
+
+// DeleteGroupHandler godoc
+// @Summary      Delete a group
+// @Description  Deletes a group with its memberships and links, and revokes the refresh tokens of its members
+// @Tags         Group
+// @Accept       json
+// @Param        id path string true "Id group"
+// @Success      200
+// @Failure      400 {object} dto.ResponseError "Failed to delete group"
+// @Router       /groups/{id} [delete]
+func (c *AppController) DeleteGroupHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.RecieveGroup)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	if err := c.Service.DeleteGroup(req, claims); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusOK)
+}
@@ -23,12 +23,23 @@ type Controller interface {
 	LoginHandler(*fiber.Ctx) error
 	LogoutHandler(*fiber.Ctx) error
 	RefreshTokenHandler(*fiber.Ctx) error
+	RegisterHandler(*fiber.Ctx) error
+	VerifyRegistrationHandler(*fiber.Ctx) error
 	// Users
 	RecieveUserHandler(*fiber.Ctx) error
 	ListUsersHandler(*fiber.Ctx) error
 	CreateUserHandler(*fiber.Ctx) error
+	ImportUsersHandler(*fiber.Ctx) error
 	UpdateUserHandler(*fiber.Ctx) error
+	ChangeUserStatusHandler(*fiber.Ctx) error
 	ChangePasswordHandler(*fiber.Ctx) error
+	EffectivePermissionsHandler(*fiber.Ctx) error
+	MyPermissionsHandler(*fiber.Ctx) error
+	MeHandler(*fiber.Ctx) error
+	ReauthenticateHandler(*fiber.Ctx) error
+	ChangeExpiredPasswordHandler(*fiber.Ctx) error
+	UpdateMeHandler(*fiber.Ctx) error
+	ConfirmEmailHandler(*fiber.Ctx) error
 	// Roles
 	ListRolesHandler(*fiber.Ctx) error
 	CreateRoleHandler(*fiber.Ctx) error
@@ -41,4 +52,38 @@ type Controller interface {
 	ListTenantHandler(*fiber.Ctx) error
 	CreateTenantHandler(*fiber.Ctx) error
 	UpdateTenantHandler(*fiber.Ctx) error
+	// Policies
+	ListPoliciesHandler(*fiber.Ctx) error
+	CreatePolicyHandler(*fiber.Ctx) error
+	UpdatePolicyHandler(*fiber.Ctx) error
+	// Relations
+	ListRelationsHandler(*fiber.Ctx) error
+	WriteRelationHandler(*fiber.Ctx) error
+	DeleteRelationHandler(*fiber.Ctx) error
+	CheckRelationHandler(*fiber.Ctx) error
+	ExpandRelationHandler(*fiber.Ctx) error
+	ListObjectsHandler(*fiber.Ctx) error
+	// Authorization
+	CheckAccessHandler(*fiber.Ctx) error
+	CheckAccessBatchHandler(*fiber.Ctx) error
+	// Access requests
+	ListAccessRequestsHandler(*fiber.Ctx) error
+	CreateAccessRequestHandler(*fiber.Ctx) error
+	ApproveAccessRequestHandler(*fiber.Ctx) error
+	DenyAccessRequestHandler(*fiber.Ctx) error
+	// Invitations
+	ListInvitationsHandler(*fiber.Ctx) error
+	CreateInvitationHandler(*fiber.Ctx) error
+	ResendInvitationHandler(*fiber.Ctx) error
+	RevokeInvitationHandler(*fiber.Ctx) error
+	AcceptInvitationHandler(*fiber.Ctx) error
+	// Separation of duties
+	SodViolationsHandler(*fiber.Ctx) error
+	// Groups
+	ListGroupsHandler(*fiber.Ctx) error
+	CreateGroupHandler(*fiber.Ctx) error
+	UpdateGroupHandler(*fiber.Ctx) error
+	AddGroupMembersHandler(*fiber.Ctx) error
+	RemoveGroupMembersHandler(*fiber.Ctx) error
+	DeleteGroupHandler(*fiber.Ctx) error
 }
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListInvitationsHandler godoc
+// @Summary      List invitations
+// @Description  Lists the invitations, newest first
+// @Tags         Invitation
+// @Produce      json
+// @Param        page query int false "Page number of invitations to retrieve"
+// @Param        limit query int false "Number of invitations to retrieve per page"
+// @Param        status query string false "Filter by status (pending, accepted, revoked, expired)"
+// @Success      200 {object} dto.ListInvitationsDto "Invitations retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve invitations"
+// @Failure      404 {object} dto.ResponseError "No invitations found"
+// @Router       /invitations [get]
+func (c *AppController) ListInvitationsHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ListInvitations)
+	invitations, err := c.Service.Invitations(req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(invitations) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "no invitations found")
+	}
+	countInvitations := uint(len(invitations))
+	if err := gorote.Pagination(req.Page, req.Limit, &invitations); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	var data []dto.InvitationDto
+	for _, invitation := range invitations {
+		data = append(data, invitation.ToInvitationDto())
+	}
+	res := &dto.ListInvitationsDto{
+		Page:  req.Page,
+		Limit: req.Limit,
+		Total: countInvitations,
+		Data:  data,
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// CreateInvitationHandler godoc
+// @Summary      Invite a user
+// @Description  Sends a signed link to the email, through which the invitee sets their profile and password and joins with the given roles and tenants
+// @Tags         Invitation
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.CreateInvitation true "Invitation data"
+// @Success      201 {object} dto.InvitationDto "Invitation sent successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to create invitation"
+// @Failure      403 {object} dto.ResponseError "Grants roles or tenants beyond the inviter's access"
+// @Failure      409 {object} dto.ResponseError "Roles break a separation-of-duties rule"
+// @Router       /invitations [post]
+func (c *AppController) CreateInvitationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.CreateInvitation)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	res, err := c.Service.CreateInvitation(ctx.UserContext(), claims, req)
+	if err != nil {
+		return grantError(err, "")
+	}
+	c.Logger.InfoContext(ctx.UserContext(), "user invited", "inviter_id", claims.ID, "invitation_id", res.ID.String())
+	return ctx.Status(fiber.StatusCreated).JSON(res.ToInvitationDto())
+}
+
+// ResendInvitationHandler godoc
+// @Summary      Resend an invitation
+// @Description  Sends a new link for a pending invitation. Previous links stop working, and an expired invitation gets a new expiry
+// @Tags         Invitation
+// @Produce      json
+// @Param        id path string true "Id invitation"
+// @Success      200 {object} dto.InvitationDto "Invitation resent successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to resend invitation"
+// @Router       /invitations/{id}/resend [post]
+func (c *AppController) ResendInvitationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.RecieveInvitation)
+	res, err := c.Service.ResendInvitation(ctx.UserContext(), req.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res.ToInvitationDto())
+}
+
+// RevokeInvitationHandler godoc
+// @Summary      Revoke an invitation
+// @Description  Revokes a pending invitation, so that its links stop working
+// @Tags         Invitation
+// @Produce      json
+// @Param        id path string true "Id invitation"
+// @Success      200 {object} dto.InvitationDto "Invitation revoked successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to revoke invitation"
+// @Router       /invitations/{id}/revoke [post]
+func (c *AppController) RevokeInvitationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.RecieveInvitation)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	res, err := c.Service.RevokeInvitation(claims, req.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res.ToInvitationDto())
+}
+
+// AcceptInvitationHandler godoc
+// @Summary      Accept an invitation
+// @Description  Creates the invited user with the profile and password they chose, using the token of the invitation link
+// @Tags         Invitation
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.AcceptInvitation true "Invitation token and profile"
+// @Success      201 {object} dto.UserDto "Invitation accepted, user created"
+// @Failure      400 {object} dto.ResponseError "Invalid or expired invitation link, or the password breaks the policy"
+// @Failure      409 {object} dto.ResponseError "Roles break a separation-of-duties rule"
+// @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
+// @Router       /auth/invitations/accept [post]
+func (c *AppController) AcceptInvitationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.AcceptInvitation)
+	user, err := c.Service.AcceptInvitation(ctx.UserContext(), req)
+	if err != nil {
+		return grantError(err, "")
+	}
+	c.Logger.InfoContext(ctx.UserContext(), "invitation accepted", "user_id", user.ID.String())
+	return ctx.Status(fiber.StatusCreated).JSON(user.ToUserDto())
+}
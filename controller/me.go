@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MeHandler godoc
+// @Summary      Current user
+// @Description  Returns the profile of the authenticated user with the tenants and permissions a new token would carry
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} dto.MeDto "User retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve user"
+// @Router       /auth/me [get]
+func (c *AppController) MeHandler(ctx *fiber.Ctx) error {
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	users, err := c.Service.Users(claims.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(users) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "id user not found")
+	}
+	return ctx.Status(fiber.StatusOK).JSON(users[0].ToMeDto())
+}
+
+// UpdateMeHandler godoc
+// @Summary      Update the current user
+// @Description  Updates the names, phones, avatar and locale of the authenticated user. A new email needs the current password, or a recent authentication, and is only set once confirmed through the link sent to it
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.UpdateMe true "Profile data"
+// @Success      200 {object} dto.MeDto "User updated successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to update user"
+// @Failure      401 {object} dto.ResponseError "Changing the email needs the current password or a recent authentication"
+// @Router       /auth/me [patch]
+func (c *AppController) UpdateMeHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.UpdateMe)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	users, err := c.Service.Users(claims.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(users) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "id user not found")
+	}
+
+	if req.Email != nil {
+		*req.Email = model.NormalizeEmail(*req.Email)
+	}
+	changeEmail := req.Email != nil && *req.Email != users[0].Email
+	if changeEmail {
+		if err := c.Service.StepUp(claims, req.CurrentPassword); err != nil {
+			return stepUpError(err)
+		}
+		if err := c.Service.RequestEmailChange(ctx.UserContext(), &users[0], *req.Email); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+	user, err := c.Service.UpdateMe(ctx, claims.ID, req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	res := user.ToMeDto()
+	if changeEmail {
+		res.PendingEmail = *req.Email
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// ConfirmEmailHandler godoc
+// @Summary      Confirm a new email
+// @Description  Sets the email carried by the confirmation link and revokes existing refresh tokens
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.ConfirmEmail true "Confirmation token"
+// @Success      200 {object} dto.UserDto "Email changed successfully"
+// @Failure      400 {object} dto.ResponseError "Invalid or expired confirmation link"
+// @Router       /auth/me/email/confirm [post]
+func (c *AppController) ConfirmEmailHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ConfirmEmail)
+	user, err := c.Service.ConfirmEmailChange(ctx.UserContext(), req.Token)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(user.ToUserDto())
+}
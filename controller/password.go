@@ -1,8 +1,6 @@
 package controller
 
 import (
-	"slices"
-
 	"github.com/go-gorote/auth/permission"
 	"github.com/go-gorote/auth/schema"
 	"github.com/go-gorote/auth/secret"
@@ -11,21 +9,25 @@ import (
 
 // ChangePasswordHandler godoc
 // @Summary      Change password
-// @Description  Changes the password of a user
+// @Description  Changes the password of a user. Users changing their own password must send the current one unless they authenticated recently. Users who must change their password call it with the restricted token returned by login
 // @Tags         Password
 // @Accept       json
 // @Param        id path string true "Id user"
 // @Param        req body schema.ChangePassword true "Password data"
 // @Success      200
 // @Failure      400 {object} dto.ResponseError "Failed to change password"
+// @Failure      401 {object} dto.ResponseError "Current password missing or incorrect"
 // @Router       /users/password/{id} [put]
 func (c *AppController) ChangePasswordHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.ChangePassword)
 	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
-	editorPermission := slices.Contains(claims.Permissions, string(permission.PermissionAdmin)) ||
-		slices.Contains(claims.Permissions, string(permission.PermissionUpdateUser)) ||
-		claims.IsSuperUser
+	editorPermission := claims.HasPermission(permission.PermissionAdmin, permission.PermissionUpdateUser)
 	editorUser := claims.ID == req.ID
+	if editorUser {
+		if err := c.Service.StepUp(claims, req.CurrentPassword); err != nil {
+			return stepUpError(err)
+		}
+	}
 	if editorPermission || editorUser || claims.IsSuperUser {
 		if err := c.Service.ChangePassword(req); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest,
@@ -37,3 +39,21 @@ func (c *AppController) ChangePasswordHandler(ctx *fiber.Ctx) error {
 
 	return ctx.SendStatus(fiber.StatusOK)
 }
+
+// ChangeExpiredPasswordHandler godoc
+// @Summary      Change an expired password
+// @Description  Replaces a password the policy considers expired. Login is refused until then, so the current credentials are sent instead of a token
+// @Tags         Password
+// @Accept       json
+// @Param        req body schema.ChangeExpiredPassword true "Current credentials and new password"
+// @Success      200
+// @Failure      400 {object} dto.ResponseError "Failed to change password"
+// @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
+// @Router       /auth/password/expired [post]
+func (c *AppController) ChangeExpiredPasswordHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ChangeExpiredPassword)
+	if err := c.Service.ChangeExpiredPassword(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "failed to change password: "+err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusOK)
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/go-gorote/auth/dto"
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"github.com/go-gorote/gorote"
 	"github.com/gofiber/fiber/v2"
 )
@@ -46,7 +47,7 @@ func (c *AppController) ListPermissiontHandler(ctx *fiber.Ctx) error {
 
 // CreatePermissiontHandler godoc
 // @Summary      Create a permission
-// @Description  Creates a permission with code, description, and active
+// @Description  Creates a permission with code, description, group, and active
 // @Tags         Permission
 // @Accept       json
 // @Produce      json
@@ -70,12 +71,15 @@ func (c *AppController) CreatePermissiontHandler(ctx *fiber.Ctx) error {
 // @Param        req body schema.UpdatePermission true "Permission data"
 // @Success      200 {object} dto.PermissionDto "Permission updated successfully"
 // @Failure      400 {object} dto.ResponseError "Failed to update permission"
+// @Failure      403 {object} dto.ResponseError "Renames or activates a permission the editor doesn't hold"
+// @Failure      409 {object} dto.ResponseError "Renames or deactivates the admin permission"
 // @Router       /permissions/{id} [put]
 func (c *AppController) UpdatePermissiontHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.UpdatePermission)
-	res, err := c.Service.UpdatePermission(req)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	res, err := c.Service.UpdatePermission(req, claims)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "failed to update permission")
+		return grantError(err, "failed to update permission")
 	}
 	return ctx.Status(fiber.StatusOK).JSON(res.ToPermissionDto())
 }
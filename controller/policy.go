@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/policy"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListPoliciesHandler godoc
+// @Summary      List all policies
+// @Description  Lists all access policies registered in the system
+// @Tags         Policy
+// @Produce      json
+// @Param        page query int false "Page number of policies to retrieve"
+// @Param        limit query int false "Number of policies to retrieve per page"
+// @Success      200 {object} dto.ListPoliciesDto "Policies retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve policies"
+// @Failure      404 {object} dto.ResponseError "No policies found"
+// @Router       /policies [get]
+func (c *AppController) ListPoliciesHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.Paginate)
+	policies, err := c.Service.Policies()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(policies) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "no policies found")
+	}
+	countPolicies := uint(len(policies))
+	if err := gorote.Pagination(req.Page, req.Limit, &policies); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	var data []dto.PolicyDto
+	for _, policy := range policies {
+		data = append(data, policy.ToPolicyDto())
+	}
+	res := &dto.ListPoliciesDto{
+		Page:  req.Page,
+		Limit: req.Limit,
+		Total: countPolicies,
+		Data:  data,
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// CreatePolicyHandler godoc
+// @Summary      Create a policy
+// @Description  Creates an access policy with effect, actions, resource and conditions
+// @Tags         Policy
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.CreatePolicy true "Policy data"
+// @Success      201 {object} dto.PolicyDto "Policy created successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to create policy"
+// @Router       /policies [post]
+func (c *AppController) CreatePolicyHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.CreatePolicy)
+	res, err := c.Service.CreatePolicy(req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(res.ToPolicyDto())
+}
+
+// UpdatePolicyHandler godoc
+// @Summary      Update a policy
+// @Description  Updates an access policy with new data
+// @Tags         Policy
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id policy"
+// @Param        req body schema.UpdatePolicy true "Policy data"
+// @Success      200 {object} dto.PolicyDto "Policy updated successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to update policy"
+// @Router       /policies/{id} [put]
+func (c *AppController) UpdatePolicyHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.UpdatePolicy)
+	res, err := c.Service.UpdatePolicy(req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res.ToPolicyDto())
+}
+
+// authorize evaluates the stored policies for the caller and returns a 403
+// when they deny the action on the resource.
+func (c *AppController) authorize(ctx *fiber.Ctx, claims *secret.JwtClaims, action, resource string, object policy.Attributes) error {
+	decision, err := c.Service.Authorize(ctx.UserContext(), policy.Request{
+		Action:   action,
+		Resource: resource,
+		Subject:  policy.Subject(claims),
+		Object:   object,
+		Context:  policy.Context(ctx),
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if !decision.Allowed {
+		return fiber.NewError(fiber.StatusForbidden, decision.Reason)
+	}
+	return nil
+}
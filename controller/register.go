@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/schema"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Register godoc
+// @Summary      Register
+// @Description  Creates an account with the default roles and tenants when self-registration is enabled. When the configuration asks for verification, the account stays inactive until the link sent to its email is followed. A taken email or username gets the same response, and its owner is notified instead
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.Register true "Profile, password and CAPTCHA response"
+// @Success      202 "Registration accepted"
+// @Failure      400 {object} dto.ResponseError "Bad request - validation error, CAPTCHA failed, or the password breaks the policy"
+// @Failure      403 {object} dto.ResponseError "Email domain is not allowed to register"
+// @Failure      404 {object} dto.ResponseError "Self-registration is disabled"
+// @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
+// @Router       /auth/register [post]
+func (c *AppController) RegisterHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.Register)
+	if err := c.Service.Register(ctx.UserContext(), req, ctx.IP()); err != nil {
+		return registerError(err)
+	}
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+// VerifyRegistration godoc
+// @Summary      Verify a registration
+// @Description  Activates the account of a registration verification link
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.VerifyRegistration true "Verification token"
+// @Success      200 {object} dto.UserDto "Account activated"
+// @Failure      400 {object} dto.ResponseError "Invalid or expired verification link"
+// @Failure      429 {object} dto.ResponseError "Too many requests - rate limit exceeded (60 requests per window)"
+// @Router       /auth/register/verify [post]
+func (c *AppController) VerifyRegistrationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.VerifyRegistration)
+	user, err := c.Service.VerifyRegistration(ctx.UserContext(), req.Token)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(user.ToUserDto())
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListRelationsHandler godoc
+// @Summary      List relation tuples
+// @Description  Lists the relation tuples stored for a namespace, optionally filtered by object and relation
+// @Tags         Relation
+// @Produce      json
+// @Param        namespace query string true "Namespace of the objects"
+// @Param        object_id query string false "Id of the object"
+// @Param        relation query string false "Relation name"
+// @Param        page query int false "Page number of tuples to retrieve"
+// @Param        limit query int false "Number of tuples to retrieve per page"
+// @Success      200 {object} dto.ListRelationTuplesDto "Relation tuples retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve relation tuples"
+// @Failure      404 {object} dto.ResponseError "No relation tuples found"
+// @Router       /relations [get]
+func (c *AppController) ListRelationsHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ListRelations)
+	tuples, err := c.Service.Relations(req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(tuples) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "no relation tuples found")
+	}
+	countTuples := uint(len(tuples))
+	if err := gorote.Pagination(req.Page, req.Limit, &tuples); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	var data []dto.RelationTupleDto
+	for _, tuple := range tuples {
+		data = append(data, tuple.ToRelationTupleDto())
+	}
+	res := &dto.ListRelationTuplesDto{
+		Page:  req.Page,
+		Limit: req.Limit,
+		Total: countTuples,
+		Data:  data,
+	}
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// WriteRelationHandler godoc
+// @Summary      Write a relation tuple
+// @Description  Stores that a user, a role or a userset has a relation on an object
+// @Tags         Relation
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.WriteRelation true "Relation tuple"
+// @Success      201 {object} dto.RelationTupleDto "Relation tuple written successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to write relation tuple"
+// @Router       /relations [post]
+func (c *AppController) WriteRelationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.WriteRelation)
+	res, err := c.Service.WriteRelation(req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(res.ToRelationTupleDto())
+}
+
+// DeleteRelationHandler godoc
+// @Summary      Delete a relation tuple
+// @Description  Removes a stored relation tuple
+// @Tags         Relation
+// @Accept       json
+// @Param        req body schema.WriteRelation true "Relation tuple"
+// @Success      200
+// @Failure      400 {object} dto.ResponseError "Failed to delete relation tuple"
+// @Router       /relations [delete]
+func (c *AppController) DeleteRelationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.WriteRelation)
+	if err := c.Service.DeleteRelation(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// CheckRelationHandler godoc
+// @Summary      Check a relation
+// @Description  Checks whether a user or a role has a relation on an object, following computed usersets
+// @Tags         Relation
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.CheckRelation true "Check data"
+// @Success      200 {object} dto.CheckRelationDto "Relation checked successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to check relation"
+// @Router       /relations/check [post]
+func (c *AppController) CheckRelationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.CheckRelation)
+	allowed, err := c.Service.CheckRelation(ctx.UserContext(), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(dto.CheckRelationDto{Allowed: allowed})
+}
+
+// ExpandRelationHandler godoc
+// @Summary      Expand a relation
+// @Description  Returns the tree of subjects and usersets that have a relation on an object
+// @Tags         Relation
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.ExpandRelation true "Expand data"
+// @Success      200 {object} dto.ExpandRelationDto "Relation expanded successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to expand relation"
+// @Router       /relations/expand [post]
+func (c *AppController) ExpandRelationHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ExpandRelation)
+	tree, err := c.Service.ExpandRelation(ctx.UserContext(), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(dto.ExpandRelationDto{Tree: tree})
+}
+
+// ListObjectsHandler godoc
+// @Summary      List objects of a subject
+// @Description  Lists the objects of a namespace on which a user or a role has a relation
+// @Tags         Relation
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.ListObjects true "List objects data"
+// @Success      200 {object} dto.ListObjectsDto "Objects listed successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to list objects"
+// @Router       /relations/list-objects [post]
+func (c *AppController) ListObjectsHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ListObjects)
+	objects, err := c.Service.ListObjects(ctx.UserContext(), req)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(dto.ListObjectsDto{
+		Namespace: req.Namespace,
+		Relation:  req.Relation,
+		ObjectIDs: objects,
+	})
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/go-gorote/auth/dto"
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"github.com/go-gorote/gorote"
 	"github.com/gofiber/fiber/v2"
 )
@@ -53,12 +54,14 @@ func (c *AppController) ListRolesHandler(ctx *fiber.Ctx) error {
 // @Param        req body schema.CreateRole true "Role data"
 // @Success      201 {object} dto.RoleDto "Role created successfully"
 // @Failure      400 {object} dto.ResponseError "Failed to create role"
+// @Failure      403 {object} dto.ResponseError "Allows permissions the editor doesn't hold"
 // @Router       /roles [post]
 func (c *AppController) CreateRoleHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.CreateRole)
-	role, err := c.Service.CreateRole(req)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	role, err := c.Service.CreateRole(req, claims)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "failed to create role")
+		return grantError(err, "failed to create role")
 	}
 	return ctx.Status(fiber.StatusCreated).JSON(role.ToRoleDto())
 }
@@ -73,12 +76,15 @@ func (c *AppController) CreateRoleHandler(ctx *fiber.Ctx) error {
 // @Param        req body schema.UpdateRole true "Role data"
 // @Success      200 {object} dto.RoleDto "Role updated successfully"
 // @Failure      400 {object} dto.ResponseError "Failed to update role"
+// @Failure      403 {object} dto.ResponseError "Allows permissions the editor doesn't hold"
+// @Failure      409 {object} dto.ResponseError "Breaks a separation-of-duties rule or removes the last admin role"
 // @Router       /roles/{id} [put]
 func (c *AppController) UpdateRoleHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.UpdateRole)
-	role, err := c.Service.UpdateRole(req)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	role, err := c.Service.UpdateRole(req, claims)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "failed to update role")
+		return grantError(err, "failed to update role")
 	}
 	return ctx.Status(fiber.StatusOK).JSON(role.ToRoleDto())
 }
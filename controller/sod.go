@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SodViolationsHandler godoc
+// @Summary      List separation-of-duties violations
+// @Description  Lists the users whose current roles break a configured separation-of-duties rule
+// @Tags         SeparationOfDuties
+// @Produce      json
+// @Success      200 {object} dto.ListSodViolationsDto "Violations retrieved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to retrieve violations"
+// @Router       /sod/violations [get]
+func (c *AppController) SodViolationsHandler(ctx *fiber.Ctx) error {
+	violations, err := c.Service.SodViolations()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	data := []dto.SodViolationDto{}
+	for _, v := range violations {
+		data = append(data, dto.SodViolationDto{
+			UserID:   v.User.ID.String(),
+			Username: v.User.Username,
+			Rule:     v.Rule,
+			Roles:    v.Roles,
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(dto.ListSodViolationsDto{
+		Total: uint(len(data)),
+		Data:  data,
+	})
+}
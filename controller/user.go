@@ -2,6 +2,7 @@ package controller
 
 import (
 	"slices"
+	"time"
 
 	"github.com/go-gorote/auth/dto"
 	"github.com/go-gorote/auth/model"
@@ -26,7 +27,7 @@ import (
 func (c *AppController) RecieveUserHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.RecieveUser)
 	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
-	editorPermission := slices.Contains(claims.Permissions, string(permission.PermissionAdmin)) || slices.Contains(claims.Permissions, string(permission.PermissionViewUser)) || claims.IsSuperUser
+	editorPermission := claims.HasPermission(permission.PermissionAdmin, permission.PermissionViewUser)
 	editorUser := claims.ID == req.ID
 
 	if !editorPermission {
@@ -43,6 +44,12 @@ func (c *AppController) RecieveUserHandler(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "id user not found")
 	}
 
+	if !editorUser {
+		if err := c.authorize(ctx, claims, string(permission.PermissionViewUser), "user", users[0].Attributes()); err != nil {
+			return err
+		}
+	}
+
 	return ctx.Status(fiber.StatusOK).JSON(users[0].ToUserDto())
 }
 
@@ -104,33 +111,61 @@ func (c *AppController) ListUsersHandler(ctx *fiber.Ctx) error {
 // @Param        is_super_user formData boolean false "User is superuser"
 // @Param        roles formData array false "List of roles"
 // @Param        tenants formData array false "List of tenants"
+// @Param        role_assignments formData array false "Time-bound roles (id, starts_at, expires_at)"
+// @Param        tenant_assignments formData array false "Time-bound tenants (id, starts_at, expires_at)"
 // @Param        phone1 formData string true "Primary phone number (E.164 format)"
 // @Param        phone2 formData string false "Secondary phone number (E.164 format)"
 // @Param        avatar formData file false "Avatar file"
-// @Param        password formData string true "Password (8-72 chars)"
-// @Success      201 {object} dto.UserDto "User created successfully"
+// @Param        password formData string false "Password following the password policy (8-72 chars), required unless generate_password is set"
+// @Param        generate_password formData boolean false "Generate a temporary password, returned once, that the user must change at first login"
+// @Param        must_change_password formData boolean false "Make the user change the password at first login"
+// @Success      201 {object} dto.CreatedUserDto "User created successfully"
 // @Failure      400 {object} dto.ResponseError "Failed to create user"
+// @Failure      403 {object} dto.ResponseError "Grants roles or tenants beyond the editor's access"
+// @Failure      409 {object} dto.ResponseError "Roles break a separation-of-duties rule"
 // @Router       /users [post]
 func (c *AppController) CreateUserHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.CreateUser)
 	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
-	if err := gorote.ValidatePassword(req.Password); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-	hashedPassword, err := gorote.HashPassword(req.Password)
+	user, temporaryPassword, err := c.Service.CreateUser(ctx, req, claims)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "crypting password failed")
+		return grantError(err, "")
 	}
-	user, err := c.Service.CreateUser(ctx, req, hashedPassword, claims.IsSuperUser)
+	return ctx.Status(fiber.StatusCreated).JSON(dto.CreatedUserDto{
+		UserDto:           user.ToUserDto(),
+		TemporaryPassword: temporaryPassword,
+	})
+}
+
+// ImportUsersHandler godoc
+// @Summary      Import users
+// @Description  Creates users migrated from another system with their password hashes: bcrypt ($2a$, $2b$, $2y$), Argon2id, Django's pbkdf2_sha256 or SHA-512 crypt ($6$). The hashes are replaced by the configured hasher at each user's first login. Either every user is imported or none
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        req body schema.ImportUsers true "Users to import"
+// @Success      201 {object} dto.ImportUsersDto "Users imported successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to import users, e.g. an unrecognized or malformed password hash, or one whose cost parameters are out of bounds"
+// @Failure      403 {object} dto.ResponseError "Grants roles or tenants beyond the editor's access"
+// @Failure      409 {object} dto.ResponseError "Roles break a separation-of-duties rule"
+// @Router       /users/import [post]
+func (c *AppController) ImportUsersHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ImportUsers)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	users, err := c.Service.ImportUsers(req, claims)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return grantError(err, "")
 	}
-	return ctx.Status(fiber.StatusCreated).JSON(user.ToUserDto())
+	res := &dto.ImportUsersDto{Total: uint(len(users))}
+	for _, user := range users {
+		res.Data = append(res.Data, user.ToUserDto())
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(res)
 }
 
 // UpdateUserHandler godoc
 // @Summary      Update a user
-// @Description  Updates a user with new data
+// @Description  Updates a user with new data. Users without update_user edit their own profile through PATCH /auth/me
 // @Tags         User
 // @Accept       json
 // @Produce      json
@@ -138,18 +173,29 @@ func (c *AppController) CreateUserHandler(ctx *fiber.Ctx) error {
 // @Param        req body schema.UpdateUser true "User data"
 // @Success      200 {object} dto.UserDto "User updated successfully"
 // @Failure      400 {object} dto.ResponseError "Failed to update user"
-// @Failure      403 {object} dto.ResponseError "You don't have permission to update this user"
+// @Failure      403 {object} dto.ResponseError "You don't have permission to update this user, or the roles or tenants exceed your access"
+// @Failure      409 {object} dto.ResponseError "Breaks a separation-of-duties rule or demotes the last super user"
 // @Router       /users/{id} [put]
 func (c *AppController) UpdateUserHandler(ctx *fiber.Ctx) error {
 	req := ctx.Locals("validatedData").(*schema.UpdateUser)
 	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
-	editorPermission := slices.Contains(claims.Permissions, string(permission.PermissionAdmin)) || slices.Contains(claims.Permissions, string(permission.PermissionUpdateUser)) || claims.IsSuperUser
-	editorUser := claims.ID == req.ID
+	editorPermission := claims.HasPermission(permission.PermissionAdmin, permission.PermissionUpdateUser)
 	var res model.User
-	if editorPermission || editorUser || claims.IsSuperUser {
-		user, err := c.Service.UpdateUser(req, claims.IsSuperUser, editorPermission)
+	if editorPermission || claims.IsSuperUser {
+		users, err := c.Service.Users(req.ID)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, "failed to update user")
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if len(users) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "id user not found")
+		}
+		if err := c.authorize(ctx, claims, string(permission.PermissionUpdateUser), "user", users[0].Attributes()); err != nil {
+			return err
+		}
+
+		user, err := c.Service.UpdateUser(req, claims, editorPermission)
+		if err != nil {
+			return grantError(err, "failed to update user")
 		}
 		res = *user
 	} else {
@@ -158,3 +204,79 @@ func (c *AppController) UpdateUserHandler(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(res.ToUserDto())
 }
+
+// ChangeUserStatusHandler godoc
+// @Summary      Change the status of a user
+// @Description  Moves a user along the account lifecycle: pending users may be activated, active ones suspended (with a reason and optionally until a time), locked or deprovisioned, suspended and locked ones reactivated, and any but deprovisioned ones deprovisioned. Changes revoke the user's refresh tokens and are audited
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Id user"
+// @Param        req body schema.ChangeUserStatus true "New status"
+// @Success      200 {object} dto.UserDto "Status changed successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to change the status"
+// @Failure      403 {object} dto.ResponseError "You don't have permission to update this user"
+// @Failure      409 {object} dto.ResponseError "Not an edge of the lifecycle, or disables the last super user"
+// @Router       /users/{id}/status [post]
+func (c *AppController) ChangeUserStatusHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.ChangeUserStatus)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	users, err := c.Service.Users(req.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(users) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "id user not found")
+	}
+	if err := c.authorize(ctx, claims, string(permission.PermissionUpdateUser), "user", users[0].Attributes()); err != nil {
+		return err
+	}
+	user, err := c.Service.ChangeUserStatus(claims, req)
+	if err != nil {
+		return grantError(err, "")
+	}
+	return ctx.Status(fiber.StatusOK).JSON(user.ToUserDto())
+}
+
+// EffectivePermissionsHandler godoc
+// @Summary      Effective permissions of a user
+// @Description  Resolves the permissions granted by the user's roles, where a deny on any role overrides an allow. Each grant names the role, group or super user status it comes from and the tenants it applies in; grants filtered out by inactive roles, permissions, groups or assignments are listed with the reason, as are the tenants filtered out by inactive tenants, groups or assignments
+// @Tags         User
+// @Produce      json
+// @Param        id path string true "Id user"
+// @Success      200 {object} dto.EffectivePermissionsDto "Effective permissions resolved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to resolve permissions"
+// @Failure      403 {object} dto.ResponseError "You don't have permission to access this route"
+// @Router       /users/{id}/effective-permissions [get]
+func (c *AppController) EffectivePermissionsHandler(ctx *fiber.Ctx) error {
+	req := ctx.Locals("validatedData").(*schema.RecieveUser)
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	editorPermission := claims.HasPermission(permission.PermissionAdmin, permission.PermissionViewUser)
+	editorUser := claims.ID == req.ID
+	if !editorPermission && !editorUser {
+		return fiber.NewError(fiber.StatusForbidden, "you don't have permission to access this route")
+	}
+	return c.effectivePermissions(ctx, req.ID)
+}
+
+// MyPermissionsHandler godoc
+// @Summary      Effective permissions of the current user
+// @Description  Same as /users/{id}/effective-permissions for the authenticated user
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} dto.EffectivePermissionsDto "Effective permissions resolved successfully"
+// @Failure      400 {object} dto.ResponseError "Failed to resolve permissions"
+// @Router       /auth/me/permissions [get]
+func (c *AppController) MyPermissionsHandler(ctx *fiber.Ctx) error {
+	claims := ctx.Locals("claimsData").(*secret.JwtClaims)
+	return c.effectivePermissions(ctx, claims.ID)
+}
+
+func (c *AppController) effectivePermissions(ctx *fiber.Ctx, userID string) error {
+	now := time.Now()
+	user, grants, err := c.Service.PermissionGrants(userID, now)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return ctx.Status(fiber.StatusOK).JSON(user.ToEffectivePermissionsDto(grants, now))
+}
@@ -0,0 +1,25 @@
+package dto
+
+type AccessRequestDto struct {
+	ID            string `json:"id"`
+	CreatedAt     string `json:"created_at"`
+	UserID        string `json:"user_id"`
+	Username      string `json:"username"`
+	RoleID        string `json:"role_id,omitempty"`
+	RoleName      string `json:"role_name,omitempty"`
+	TenantID      string `json:"tenant_id,omitempty"`
+	TenantName    string `json:"tenant_name,omitempty"`
+	Justification string `json:"justification"`
+	Duration      string `json:"duration,omitempty"`
+	Status        string `json:"status"`
+	ReviewerID    string `json:"reviewer_id,omitempty"`
+	ReviewedAt    string `json:"reviewed_at,omitempty"`
+	ReviewNote    string `json:"review_note,omitempty"`
+}
+
+type ListAccessRequestsDto struct {
+	Page  uint               `json:"page"`
+	Limit uint               `json:"limit"`
+	Total uint               `json:"total"`
+	Data  []AccessRequestDto `json:"data"`
+}
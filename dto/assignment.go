@@ -0,0 +1,9 @@
+package dto
+
+type AssignmentDto struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	StartsAt  string `json:"starts_at,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Active    bool   `json:"active"`
+}
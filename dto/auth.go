@@ -3,4 +3,7 @@ package dto
 type Token struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	// MustChangePassword tells that AccessToken only allows changing the
+	// password and that no refresh token was issued.
+	MustChangePassword bool `json:"must_change_password,omitempty"`
 }
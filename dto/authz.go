@@ -0,0 +1,13 @@
+package dto
+
+type AuthzDecisionDto struct {
+	SubjectID   string   `json:"subject_id,omitempty"`
+	Permissions []string `json:"permissions"`
+	Tenant      string   `json:"tenant,omitempty"`
+	Allowed     bool     `json:"allowed"`
+	Reason      string   `json:"reason"`
+}
+
+type AuthzBatchDto struct {
+	Results []AuthzDecisionDto `json:"results"`
+}
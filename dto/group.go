@@ -0,0 +1,30 @@
+package dto
+
+type GroupMemberDto struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type GroupDto struct {
+	ID          string           `json:"id"`
+	UpdatedAt   string           `json:"updated_at"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Members     []GroupMemberDto `json:"members"`
+	Roles       []RoleDto        `json:"roles"`
+	Tenants     []TenantDto      `json:"tenants"`
+	Active      bool             `json:"active"`
+}
+
+type GroupRefDto struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ListGroupsDto struct {
+	Page  uint       `json:"page"`
+	Limit uint       `json:"limit"`
+	Total uint       `json:"total"`
+	Data  []GroupDto `json:"data"`
+}
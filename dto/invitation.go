@@ -0,0 +1,23 @@
+package dto
+
+type InvitationDto struct {
+	ID              string      `json:"id"`
+	CreatedAt       string      `json:"created_at"`
+	Email           string      `json:"email"`
+	Roles           []RoleDto   `json:"roles"`
+	Tenants         []TenantDto `json:"tenants"`
+	InviterID       string      `json:"inviter_id"`
+	InviterUsername string      `json:"inviter_username"`
+	Status          string      `json:"status"`
+	ExpiresAt       string      `json:"expires_at"`
+	SentAt          string      `json:"sent_at"`
+	UserID          string      `json:"user_id,omitempty"`
+	AcceptedAt      string      `json:"accepted_at,omitempty"`
+}
+
+type ListInvitationsDto struct {
+	Page  uint            `json:"page"`
+	Limit uint            `json:"limit"`
+	Total uint            `json:"total"`
+	Data  []InvitationDto `json:"data"`
+}
@@ -5,7 +5,10 @@ type PermissionDto struct {
 	UpdatedAt   string `json:"updated_at"`
 	Code        string `json:"code"`
 	Description string `json:"description"`
+	Group       string `json:"group"`
 	Active      bool   `json:"active"`
+	Effect      string `json:"effect,omitempty"`
+	ManagedBy   string `json:"managed_by"`
 }
 
 type ListPermissionsDto struct {
@@ -14,3 +17,32 @@ type ListPermissionsDto struct {
 	Total uint            `json:"total"`
 	Data  []PermissionDto `json:"data"`
 }
+
+type EffectivePermissionsDto struct {
+	UserID      string               `json:"user_id"`
+	IsSuperUser bool                 `json:"is_super_user"`
+	Allowed     []string             `json:"allowed"`
+	Denied      []string             `json:"denied"`
+	Tenants     []string             `json:"tenants"`
+	Grants      []PermissionGrantDto `json:"grants"`
+	Filtered    []PermissionGrantDto `json:"filtered"`
+
+	FilteredTenants []TenantGrantDto `json:"filtered_tenants"`
+}
+
+type PermissionGrantDto struct {
+	Code    string   `json:"code"`
+	Effect  string   `json:"effect"`
+	Source  string   `json:"source"`
+	Role    string   `json:"role,omitempty"`
+	Group   string   `json:"group,omitempty"`
+	Tenants []string `json:"tenants"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+type TenantGrantDto struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Group  string `json:"group,omitempty"`
+	Reason string `json:"reason"`
+}
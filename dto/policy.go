@@ -0,0 +1,27 @@
+package dto
+
+type ConditionDto struct {
+	Attribute string `json:"attribute"`
+	Operator  string `json:"operator"`
+	Value     any    `json:"value,omitempty"`
+	Ref       string `json:"ref,omitempty"`
+}
+
+type PolicyDto struct {
+	ID          string         `json:"id"`
+	UpdatedAt   string         `json:"updated_at"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Effect      string         `json:"effect"`
+	Actions     []string       `json:"actions"`
+	Resource    string         `json:"resource"`
+	Conditions  []ConditionDto `json:"conditions"`
+	Active      bool           `json:"active"`
+}
+
+type ListPoliciesDto struct {
+	Page  uint        `json:"page"`
+	Limit uint        `json:"limit"`
+	Total uint        `json:"total"`
+	Data  []PolicyDto `json:"data"`
+}
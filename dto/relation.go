@@ -0,0 +1,36 @@
+package dto
+
+import "github.com/go-gorote/auth/rebac"
+
+type RelationTupleDto struct {
+	ID              string `json:"id"`
+	UpdatedAt       string `json:"updated_at"`
+	Namespace       string `json:"namespace"`
+	ObjectID        string `json:"object_id"`
+	Relation        string `json:"relation"`
+	SubjectType     string `json:"subject_type"`
+	SubjectID       string `json:"subject_id"`
+	SubjectRelation string `json:"subject_relation,omitempty"`
+	Tuple           string `json:"tuple"`
+}
+
+type ListRelationTuplesDto struct {
+	Page  uint               `json:"page"`
+	Limit uint               `json:"limit"`
+	Total uint               `json:"total"`
+	Data  []RelationTupleDto `json:"data"`
+}
+
+type CheckRelationDto struct {
+	Allowed bool `json:"allowed"`
+}
+
+type ExpandRelationDto struct {
+	Tree *rebac.Node `json:"tree"`
+}
+
+type ListObjectsDto struct {
+	Namespace string   `json:"namespace"`
+	Relation  string   `json:"relation"`
+	ObjectIDs []string `json:"object_ids"`
+}
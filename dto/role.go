@@ -7,6 +7,7 @@ type RoleDto struct {
 	Description string          `json:"description"`
 	Permissions []PermissionDto `json:"permissions"`
 	Active      bool            `json:"active"`
+	ManagedBy   string          `json:"managed_by"`
 }
 
 type ListRolesDto struct {
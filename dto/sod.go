@@ -0,0 +1,13 @@
+package dto
+
+type SodViolationDto struct {
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	Rule     string   `json:"rule"`
+	Roles    []string `json:"roles"`
+}
+
+type ListSodViolationsDto struct {
+	Total uint              `json:"total"`
+	Data  []SodViolationDto `json:"data"`
+}
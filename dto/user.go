@@ -13,7 +13,23 @@ type UserDto struct {
 	Roles       []RoleDto   `json:"roles"`
 	Tenants     []TenantDto `json:"tenants"`
 	Avatar      string      `json:"avatar"`
+	Locale      string      `json:"locale,omitempty"`
 	Active      bool        `json:"active"`
+	Status      string      `json:"status"`
+
+	SuspendedReason    string          `json:"suspended_reason,omitempty"`
+	SuspendedUntil     string          `json:"suspended_until,omitempty"`
+	MustChangePassword bool            `json:"must_change_password"`
+	RoleAssignments    []AssignmentDto `json:"role_assignments"`
+	TenantAssignments  []AssignmentDto `json:"tenant_assignments"`
+	Groups             []GroupRefDto   `json:"groups"`
+}
+
+// CreatedUserDto is the created user with the temporary password generated
+// for them, which is shown only once.
+type CreatedUserDto struct {
+	UserDto
+	TemporaryPassword string `json:"temporary_password,omitempty"`
 }
 
 type ListUsersDto struct {
@@ -22,3 +38,17 @@ type ListUsersDto struct {
 	Total uint      `json:"total"`
 	Data  []UserDto `json:"data"`
 }
+
+type ImportUsersDto struct {
+	Total uint      `json:"total"`
+	Data  []UserDto `json:"data"`
+}
+
+type MeDto struct {
+	User        UserDto  `json:"user"`
+	Tenants     []string `json:"tenants"`
+	Permissions []string `json:"permissions"`
+	Denied      []string `json:"denied"`
+	// PendingEmail is set when a change of email waits for confirmation.
+	PendingEmail string `json:"pending_email,omitempty"`
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-gorote/auth/model"
+	"gorm.io/gorm"
+)
+
+// ErrIdentityCollision is returned when stored users would share an email
+// or username once normalized.
+var ErrIdentityCollision = errors.New("users share an email or username once normalized")
+
+// IdentityCollision is a normalized email or username held by several
+// users, listed as "id (stored value)".
+type IdentityCollision struct {
+	Field      string   `json:"field"`
+	Normalized string   `json:"normalized"`
+	Users      []string `json:"users"`
+}
+
+// IdentityReport describes the stored emails and usernames that aren't
+// normalized.
+type IdentityReport struct {
+	// Changed counts the users whose email or username is normalized, or
+	// would be without collisions.
+	Changed    int                 `json:"changed"`
+	Collisions []IdentityCollision `json:"collisions"`
+}
+
+func (r *IdentityReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d users to normalize\n", r.Changed)
+	for _, c := range r.Collisions {
+		fmt.Fprintf(&b, "collision on %s %s: %s\n", c.Field, c.Normalized, strings.Join(c.Users, ", "))
+	}
+	return b.String()
+}
+
+// identityIndexes are the case-insensitive unique indexes enforced once
+// the stored identities are normalized.
+var identityIndexes = map[string]string{
+	"idx_users_email_lower":    "email",
+	"idx_users_username_lower": "username",
+}
+
+// NormalizeIdentities rewrites the stored emails and usernames in the form
+// of model.NormalizeEmail and model.NormalizeUsername and enforces
+// case-insensitive unique indexes on them. When users collide once
+// normalized, nothing is changed and the report lists them along with
+// ErrIdentityCollision, to be resolved by hand first. With dryRun only the
+// report is made.
+func NormalizeIdentities(db *gorm.DB, dryRun bool) (*IdentityReport, error) {
+	var users []model.User
+	if err := db.Unscoped().Select("id", "email", "username").Order("created_at").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+
+	report := &IdentityReport{Collisions: []IdentityCollision{}}
+	emails := map[string][]model.User{}
+	usernames := map[string][]model.User{}
+	var changed []model.User
+	for _, user := range users {
+		email, username := model.NormalizeEmail(user.Email), model.NormalizeUsername(user.Username)
+		emails[email] = append(emails[email], user)
+		usernames[username] = append(usernames[username], user)
+		if email != user.Email || username != user.Username {
+			changed = append(changed, user)
+		}
+	}
+	report.Changed = len(changed)
+	report.Collisions = append(report.Collisions, collisions("email", emails, func(u model.User) string { return u.Email })...)
+	report.Collisions = append(report.Collisions, collisions("username", usernames, func(u model.User) string { return u.Username })...)
+	if len(report.Collisions) > 0 {
+		return report, ErrIdentityCollision
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for _, user := range changed {
+			if err := tx.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]any{
+				"email":    model.NormalizeEmail(user.Email),
+				"username": model.NormalizeUsername(user.Username),
+			}).Error; err != nil {
+				return fmt.Errorf("failed to normalize user %s: %w", user.ID, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for name, column := range identityIndexes {
+		if db.Migrator().HasIndex(&model.User{}, name) {
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON users ((LOWER(%s)))", name, column)).Error; err != nil {
+			return nil, fmt.Errorf("failed to create index %s: %w", name, err)
+		}
+	}
+	return report, nil
+}
+
+func collisions(field string, byValue map[string][]model.User, value func(model.User) string) []IdentityCollision {
+	var found []IdentityCollision
+	for normalized, users := range byValue {
+		if len(users) < 2 {
+			continue
+		}
+		c := IdentityCollision{Field: field, Normalized: normalized}
+		for _, user := range users {
+			c.Users = append(c.Users, fmt.Sprintf("%s (%s)", user.ID, value(user)))
+		}
+		found = append(found, c)
+	}
+	slices.SortFunc(found, func(a, b IdentityCollision) int {
+		return strings.Compare(a.Normalized, b.Normalized)
+	})
+	return found
+}
@@ -1,20 +1,48 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/go-gorote/auth/base"
 	"github.com/go-gorote/auth/controller"
+	"github.com/go-gorote/auth/manifest"
 	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/passwd"
 	"github.com/go-gorote/auth/permission"
 	"github.com/go-gorote/auth/router"
 	"github.com/go-gorote/auth/service"
+	"github.com/go-gorote/auth/sod"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"gorm.io/gorm"
 )
 
 func New(config base.Config) (*router.AppRouter, error) {
-	if err := setPermissions(config.DB); err != nil {
+	if err := setupJoinTables(config.DB); err != nil {
+		return nil, err
+	}
+	if err := sod.Validate(config.SeparationOfDuties); err != nil {
+		return nil, err
+	}
+	definitions, err := config.Permissions.Definitions()
+	if err != nil {
+		return nil, err
+	}
+	if err := setPermissions(config.DB, definitions); err != nil {
 		return nil, err
 	}
+	if config.PolicyFile != "" {
+		diff, err := syncPolicyFile(config.DB, config.PolicyFile, config.PolicySyncMode, false, definitions)
+		if err != nil {
+			return nil, err
+		}
+		otelslog.NewLogger("sync").Info("policy file synced",
+			"file", config.PolicyFile,
+			"changes", len(diff.Changes),
+		)
+	}
 
 	service := service.AppService{
 		Config: config,
@@ -22,6 +50,22 @@ func New(config base.Config) (*router.AppRouter, error) {
 			"app_version", config.AppVersion,
 			"app_name", config.AppName,
 		),
+		Decisions: service.NewDecisionCache(config.AuthzCacheTTL),
+		Statuses:  service.NewStatusCache(config.AccountStatusCacheTTL),
+	}
+	if config.BreachedPasswordsFile != "" {
+		breached, err := passwd.OpenCorpus(config.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+		service.Breached = breached
+	}
+	if config.AssignmentSweepInterval > 0 {
+		ctx := config.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		service.StartAssignmentSweeper(ctx, config.AssignmentSweepInterval)
 	}
 
 	controller := controller.AppController{
@@ -39,58 +83,151 @@ func New(config base.Config) (*router.AppRouter, error) {
 		PublicKey:  &config.PrivateKey.PublicKey,
 		Storage:    config.Storage,
 		Controller: &controller,
+		Authorizer: &service,
+		Relations:  service.Checker(),
+		Accounts:   service.AccountStatus,
 	}
 
 	return &router, nil
 }
 
 func Migrate(db *gorm.DB) error {
+	if err := setupJoinTables(db); err != nil {
+		return err
+	}
 	if err := db.AutoMigrate(
 		&model.User{},
 		&model.Role{},
 		&model.Permission{},
 		&model.Tenant{},
+		&model.Policy{},
+		&model.RelationTuple{},
+		&model.AccessRequest{},
+		&model.Group{},
+		&model.AuditEvent{},
+		&model.PasswordHistory{},
+		&model.Invitation{},
 	); err != nil {
 		return err
 	}
+	// Users inactive before the status was tracked are pending, as
+	// CreateUser and ImportUsers map inactive users: registrations waiting
+	// for their link can still be verified and admins can activate the rest.
+	if err := db.Model(&model.User{}).
+		Where("active = ? AND status = ? AND status_changed_at IS NULL", false, model.UserActive).
+		UpdateColumn("status", model.UserPending).Error; err != nil {
+		return err
+	}
+	if report, err := NormalizeIdentities(db, false); err != nil {
+		if errors.Is(err, ErrIdentityCollision) {
+			return fmt.Errorf("%w:\n%s", err, strings.TrimSuffix(report.String(), "\n"))
+		}
+		return err
+	}
 	return nil
 }
 
-func setPermissions(db *gorm.DB) error {
-	permissions := []permission.PermissionCode{
-		// Admin
-		permission.PermissionAdmin,
-		// Users
-		permission.PermissionViewUser,
-		permission.PermissionCreateUser,
-		permission.PermissionUpdateUser,
-		// Permissions
-		permission.PermissionViewPermission,
-		permission.PermissionCreatePermission,
-		permission.PermissionUpdatePermission,
-		// Roles
-		permission.PermissionViewRole,
-		permission.PermissionCreateRole,
-		permission.PermissionUpdateRole,
-		// Tenants
-		permission.PermissionViewTenant,
-		permission.PermissionCreateTenant,
-		permission.PermissionUpdateTenant,
-	}
-	for _, permission := range permissions {
-		var p model.Permission
-		if err := db.Where("code = ?", string(permission)).First(&p).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				p = model.Permission{Code: string(permission), Active: true}
-				if err := db.Create(&p).Error; err != nil {
+// SyncPolicyFile applies the permissions and roles declared in the policy
+// file. With dryRun the changes are returned without being applied.
+func SyncPolicyFile(db *gorm.DB, path string, mode manifest.Mode, dryRun bool) (*manifest.Diff, error) {
+	if err := setupJoinTables(db); err != nil {
+		return nil, err
+	}
+	return syncPolicyFile(db, path, mode, dryRun, permission.Builtin)
+}
+
+// errDryRun rolls back the permissions seeded for a dry run of a sync.
+var errDryRun = errors.New("dry run")
+
+// syncPolicyFile seeds the definitions and syncs the file over them in one
+// transaction, which a dry run rolls back so the database is left as is.
+func syncPolicyFile(db *gorm.DB, path string, mode manifest.Mode, dryRun bool, definitions []permission.Definition) (*manifest.Diff, error) {
+	file, err := manifest.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	var protected []string
+	for _, definition := range definitions {
+		protected = append(protected, string(definition.Code))
+	}
+	var diff *manifest.Diff
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := setPermissions(tx, definitions); err != nil {
+			return err
+		}
+		if diff, err = manifest.Sync(tx, file, manifest.Options{
+			Mode:      mode,
+			DryRun:    dryRun,
+			Protected: protected,
+		}); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	}); err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return diff, nil
+}
+
+func setupJoinTables(db *gorm.DB) error {
+	if err := db.SetupJoinTable(&model.Role{}, "Permissions", &model.RolePermission{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&model.User{}, "Roles", &model.UserRole{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&model.User{}, "Tenants", &model.UserTenant{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// setPermissions seeds the given definitions. Existing permissions only get
+// their description and group refreshed; default roles are linked when the
+// permission is first created, so later changes made by admins are kept.
+func setPermissions(db *gorm.DB, definitions []permission.Definition) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, definition := range definitions {
+			var p model.Permission
+			err := tx.Where("code = ?", string(definition.Code)).First(&p).Error
+			if err == nil {
+				if p.Description == definition.Description && p.Group == definition.Group {
+					continue
+				}
+				if err := tx.Model(&p).Updates(map[string]any{
+					"description": definition.Description,
+					"group":       definition.Group,
+				}).Error; err != nil {
 					return err
 				}
-			} else {
+				continue
+			}
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+
+			p = model.Permission{
+				Code:        string(definition.Code),
+				Description: definition.Description,
+				Group:       definition.Group,
+				Active:      true,
+			}
+			if err := tx.Create(&p).Error; err != nil {
 				return err
 			}
-		} else {
-			continue
+			for _, name := range definition.DefaultRoles {
+				var role model.Role
+				if err := tx.Where(model.Role{Name: name}).Attrs(model.Role{Active: true}).FirstOrCreate(&role).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&role).Association("Permissions").Append(&p); err != nil {
+					return err
+				}
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
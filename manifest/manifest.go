@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gorote/auth/permission"
+	"go.yaml.in/yaml/v3"
+)
+
+// File declares the permissions and roles an application expects to exist.
+//
+//	permissions:
+//	  - code: view_invoice
+//	    description: View invoices
+//	roles:
+//	  - name: billing
+//	    permissions: [view_invoice]
+//	    deny: [delete_invoice]
+type File struct {
+	Permissions []PermissionSpec `json:"permissions" yaml:"permissions"`
+	Roles       []RoleSpec       `json:"roles" yaml:"roles"`
+}
+
+type PermissionSpec struct {
+	Code        string `json:"code" yaml:"code"`
+	Description string `json:"description" yaml:"description"`
+	Active      *bool  `json:"active,omitempty" yaml:"active,omitempty"`
+}
+
+type RoleSpec struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	Active      *bool    `json:"active,omitempty" yaml:"active,omitempty"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+	Deny        []string `json:"deny" yaml:"deny"`
+}
+
+// Load reads a policy file. Files ending in .json are parsed as JSON and
+// every other file as YAML.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	format := "yaml"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = "json"
+	}
+	return Parse(data, format)
+}
+
+func Parse(data []byte, format string) (*File, error) {
+	var file File
+	switch format {
+	case "json":
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid policy file: %w", err)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid policy file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy file format %q", format)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (f *File) Validate() error {
+	codes := map[string]bool{}
+	for _, p := range f.Permissions {
+		if p.Code == "" {
+			return fmt.Errorf("permission code is required")
+		}
+		if codes[p.Code] {
+			return fmt.Errorf("permission %s is declared twice", p.Code)
+		}
+		if p.Code == string(permission.PermissionAdmin) && !active(p.Active) {
+			return fmt.Errorf("permission %s can't be deactivated", p.Code)
+		}
+		codes[p.Code] = true
+	}
+	names := map[string]bool{}
+	for _, r := range f.Roles {
+		if r.Name == "" {
+			return fmt.Errorf("role name is required")
+		}
+		if names[r.Name] {
+			return fmt.Errorf("role %s is declared twice", r.Name)
+		}
+		names[r.Name] = true
+		for _, code := range r.Deny {
+			for _, allowed := range r.Permissions {
+				if code == allowed {
+					return fmt.Errorf("role %s both allows and denies %s", r.Name, code)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func active(v *bool) bool {
+	return v == nil || *v
+}
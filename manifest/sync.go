@@ -0,0 +1,326 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-gorote/auth/model"
+	"gorm.io/gorm"
+)
+
+type Mode string
+
+const (
+	// ModeCreate only creates the permissions and roles that are missing.
+	ModeCreate Mode = "create"
+	// ModeUpdate also brings existing records in line with the file.
+	ModeUpdate Mode = "update"
+	// ModePrune also deletes records created by a previous sync that are no
+	// longer declared. User managed records are never deleted, nor are roles
+	// with pending access requests.
+	ModePrune Mode = "prune"
+)
+
+type Options struct {
+	Mode   Mode
+	DryRun bool
+	// Protected lists permission codes that prune must keep, such as the
+	// codes the module itself relies on.
+	Protected []string
+}
+
+type Change struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Diff struct {
+	DryRun  bool     `json:"dry_run"`
+	Changes []Change `json:"changes"`
+}
+
+func (d *Diff) add(action, kind, name, detail string) {
+	d.Changes = append(d.Changes, Change{Action: action, Kind: kind, Name: name, Detail: detail})
+}
+
+func (d *Diff) String() string {
+	if len(d.Changes) == 0 {
+		return "no changes\n"
+	}
+	var b strings.Builder
+	for _, c := range d.Changes {
+		fmt.Fprintf(&b, "%s %s %s", c.Action, c.Kind, c.Name)
+		if c.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", c.Detail)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModeCreate:
+		return ModeCreate, nil
+	case ModeUpdate, ModePrune:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("invalid sync mode %q", s)
+}
+
+// Sync applies the file to the database and returns the changes it made.
+// With DryRun the changes are only computed.
+func Sync(db *gorm.DB, file *File, opts Options) (*Diff, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeCreate
+	}
+	diff := &Diff{DryRun: opts.DryRun, Changes: []Change{}}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		s := syncer{tx: tx, file: file, opts: opts, diff: diff}
+		if err := s.permissions(); err != nil {
+			return err
+		}
+		return s.roles()
+	}); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+type syncer struct {
+	tx    *gorm.DB
+	file  *File
+	opts  Options
+	diff  *Diff
+	codes map[string]model.Permission
+}
+
+func (s *syncer) apply(fn func() error) error {
+	if s.opts.DryRun {
+		return nil
+	}
+	return fn()
+}
+
+func (s *syncer) permissions() error {
+	var existing []model.Permission
+	if err := s.tx.Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to fetch permissions: %w", err)
+	}
+	s.codes = map[string]model.Permission{}
+	for _, p := range existing {
+		s.codes[p.Code] = p
+	}
+
+	for _, spec := range s.file.Permissions {
+		current, ok := s.codes[spec.Code]
+		if !ok {
+			p := model.Permission{
+				Code:        spec.Code,
+				Description: spec.Description,
+				Active:      active(spec.Active),
+				ManagedBy:   model.ManagedByPolicyFile,
+			}
+			s.diff.add("create", "permission", spec.Code, "")
+			if err := s.apply(func() error { return s.tx.Create(&p).Error }); err != nil {
+				return fmt.Errorf("failed to create permission %s: %w", spec.Code, err)
+			}
+			s.codes[spec.Code] = p
+			continue
+		}
+		if s.opts.Mode == ModeCreate {
+			continue
+		}
+		if current.Description == spec.Description && current.Active == active(spec.Active) {
+			continue
+		}
+		s.diff.add("update", "permission", spec.Code, fmt.Sprintf("description=%q active=%t", spec.Description, active(spec.Active)))
+		if err := s.apply(func() error {
+			return s.tx.Model(&current).Select("description", "active").Updates(model.Permission{
+				Description: spec.Description,
+				Active:      active(spec.Active),
+			}).Error
+		}); err != nil {
+			return fmt.Errorf("failed to update permission %s: %w", spec.Code, err)
+		}
+	}
+
+	if s.opts.Mode != ModePrune {
+		return nil
+	}
+	for _, p := range existing {
+		declared := slices.ContainsFunc(s.file.Permissions, func(spec PermissionSpec) bool { return spec.Code == p.Code })
+		if declared || p.UserManaged() || slices.Contains(s.opts.Protected, p.Code) {
+			continue
+		}
+		s.diff.add("delete", "permission", p.Code, "")
+		if err := s.apply(func() error {
+			if err := s.tx.Where("permission_id = ?", p.ID).Delete(&model.RolePermission{}).Error; err != nil {
+				return err
+			}
+			return s.tx.Unscoped().Delete(&p).Error
+		}); err != nil {
+			return fmt.Errorf("failed to delete permission %s: %w", p.Code, err)
+		}
+		delete(s.codes, p.Code)
+	}
+	return nil
+}
+
+func (s *syncer) roles() error {
+	var existing []model.Role
+	if err := s.tx.Preload("Permissions").Preload("Grants").Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to fetch roles: %w", err)
+	}
+
+	for _, spec := range s.file.Roles {
+		grants, err := s.grants(spec)
+		if err != nil {
+			return err
+		}
+		idx := slices.IndexFunc(existing, func(r model.Role) bool { return r.Name == spec.Name })
+		if idx < 0 {
+			role := model.Role{
+				Name:        spec.Name,
+				Description: spec.Description,
+				Active:      active(spec.Active),
+				ManagedBy:   model.ManagedByPolicyFile,
+			}
+			s.diff.add("create", "role", spec.Name, describeGrants(grants))
+			if err := s.apply(func() error {
+				if err := s.tx.Omit("Permissions", "Grants").Create(&role).Error; err != nil {
+					return err
+				}
+				return s.replaceGrants(&role, grants)
+			}); err != nil {
+				return fmt.Errorf("failed to create role %s: %w", spec.Name, err)
+			}
+			continue
+		}
+		if s.opts.Mode == ModeCreate {
+			continue
+		}
+
+		role := existing[idx]
+		if role.Description != spec.Description || role.Active != active(spec.Active) {
+			s.diff.add("update", "role", spec.Name, fmt.Sprintf("description=%q active=%t", spec.Description, active(spec.Active)))
+			if err := s.apply(func() error {
+				return s.tx.Model(&role).Select("description", "active").Updates(model.Role{
+					Description: spec.Description,
+					Active:      active(spec.Active),
+				}).Error
+			}); err != nil {
+				return fmt.Errorf("failed to update role %s: %w", spec.Name, err)
+			}
+		}
+		current := map[string]model.Effect{}
+		for _, p := range role.Permissions {
+			current[p.Code] = role.EffectOf(p.ID)
+		}
+		if !sameGrants(current, grants) {
+			s.diff.add("update", "role", spec.Name, describeGrants(grants))
+			if err := s.apply(func() error { return s.replaceGrants(&role, grants) }); err != nil {
+				return fmt.Errorf("failed to update role %s permissions: %w", spec.Name, err)
+			}
+		}
+	}
+
+	if s.opts.Mode != ModePrune {
+		return nil
+	}
+	for _, role := range existing {
+		declared := slices.ContainsFunc(s.file.Roles, func(spec RoleSpec) bool { return spec.Name == role.Name })
+		if declared || role.UserManaged() {
+			continue
+		}
+		// Pending requests would be left asking for nothing, so the role
+		// stays until they are reviewed.
+		var pending int64
+		if err := s.tx.Model(&model.AccessRequest{}).
+			Where("role_id = ? AND status = ?", role.ID, model.AccessRequestPending).
+			Count(&pending).Error; err != nil {
+			return fmt.Errorf("failed to fetch access requests: %w", err)
+		}
+		if pending > 0 {
+			s.diff.add("skip", "role", role.Name, fmt.Sprintf("%d pending access requests", pending))
+			continue
+		}
+		s.diff.add("delete", "role", role.Name, "")
+		if err := s.apply(func() error {
+			if err := s.tx.Where("role_id = ?", role.ID).Delete(&model.RolePermission{}).Error; err != nil {
+				return err
+			}
+			for _, table := range []string{"users_roles", "groups_roles", "invitations_roles"} {
+				if err := s.tx.Table(table).Where("role_id = ?", role.ID).Delete(nil).Error; err != nil {
+					return err
+				}
+			}
+			if err := s.tx.Model(&model.AccessRequest{}).
+				Where("role_id = ?", role.ID).
+				Update("role_id", nil).Error; err != nil {
+				return err
+			}
+			return s.tx.Unscoped().Omit("Permissions", "Grants").Delete(&role).Error
+		}); err != nil {
+			return fmt.Errorf("failed to delete role %s: %w", role.Name, err)
+		}
+	}
+	return nil
+}
+
+// grants resolves the codes of a role spec to their effects.
+func (s *syncer) grants(spec RoleSpec) (map[string]model.Effect, error) {
+	grants := map[string]model.Effect{}
+	for _, code := range spec.Permissions {
+		grants[code] = model.EffectAllow
+	}
+	for _, code := range spec.Deny {
+		grants[code] = model.EffectDeny
+	}
+	for code := range grants {
+		if _, ok := s.codes[code]; !ok {
+			return nil, fmt.Errorf("role %s references unknown permission %s", spec.Name, code)
+		}
+	}
+	return grants, nil
+}
+
+func (s *syncer) replaceGrants(role *model.Role, grants map[string]model.Effect) error {
+	if err := s.tx.Where("role_id = ?", role.ID).Delete(&model.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for code, effect := range grants {
+		if err := s.tx.Create(&model.RolePermission{
+			RoleID:       role.ID,
+			PermissionID: s.codes[code].ID,
+			Effect:       effect,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameGrants(a, b map[string]model.Effect) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for code, effect := range a {
+		if b[code] != effect {
+			return false
+		}
+	}
+	return true
+}
+
+func describeGrants(grants map[string]model.Effect) string {
+	var parts []string
+	for code, effect := range grants {
+		parts = append(parts, fmt.Sprintf("%s:%s", effect, code))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,298 @@
+package manifest_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	auth "github.com/go-gorote/auth"
+	"github.com/go-gorote/auth/manifest"
+	"github.com/go-gorote/auth/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const policyFile = `
+permissions:
+  - code: view_invoice
+    description: View invoices
+  - code: pay_invoice
+    description: Pay invoices
+roles:
+  - name: billing
+    permissions: [view_invoice, pay_invoice]
+  - name: auditor
+    permissions: [view_invoice]
+    deny: [pay_invoice]
+`
+
+// migratedDB returns an empty database in the test's temporary directory.
+func migratedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "auth.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// openDB returns a database with the records a previous sync and an admin
+// would have left.
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := migratedDB(t)
+	view := model.Permission{Code: "view_invoice", Description: "Old", Active: true, ManagedBy: model.ManagedByPolicyFile}
+	for _, p := range []*model.Permission{
+		&view,
+		{Code: "legacy_invoice", Active: true, ManagedBy: model.ManagedByPolicyFile},
+		{Code: "builtin_invoice", Active: true, ManagedBy: model.ManagedByPolicyFile},
+		{Code: "custom_invoice", Active: true},
+	} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []*model.Role{
+		{Name: "billing", Active: true, ManagedBy: model.ManagedByPolicyFile, Permissions: []model.Permission{view}},
+		{Name: "stale", Active: true, ManagedBy: model.ManagedByPolicyFile},
+		{Name: "manual", Active: true},
+	} {
+		if err := db.Create(r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestSync(t *testing.T) {
+	file, err := manifest.Parse([]byte(policyFile), "yaml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	created := []string{
+		"create permission pay_invoice",
+		"create role auditor",
+	}
+	updated := []string{
+		"update permission view_invoice",
+		"create permission pay_invoice",
+		"update role billing",
+		"create role auditor",
+	}
+	tests := []struct {
+		name        string
+		mode        manifest.Mode
+		changes     []string
+		permissions []string
+		roles       []string
+	}{
+		{"create", manifest.ModeCreate, created,
+			[]string{"builtin_invoice", "custom_invoice", "legacy_invoice", "pay_invoice", "view_invoice"},
+			[]string{"auditor", "billing", "manual", "stale"}},
+		{"update", manifest.ModeUpdate, updated,
+			[]string{"builtin_invoice", "custom_invoice", "legacy_invoice", "pay_invoice", "view_invoice"},
+			[]string{"auditor", "billing", "manual", "stale"}},
+		{"prune", manifest.ModePrune, append(updated[:2:2], "delete permission legacy_invoice", "update role billing", "create role auditor", "delete role stale"),
+			[]string{"builtin_invoice", "custom_invoice", "pay_invoice", "view_invoice"},
+			[]string{"auditor", "billing", "manual"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			opts := manifest.Options{Mode: tt.mode, Protected: []string{"builtin_invoice"}}
+
+			opts.DryRun = true
+			diff, err := manifest.Sync(db, file, opts)
+			if err != nil {
+				t.Fatalf("Sync() dry run error = %v", err)
+			}
+			if got := changes(diff); !reflect.DeepEqual(got, tt.changes) {
+				t.Errorf("Sync() dry run changes = %q, want %q", got, tt.changes)
+			}
+			if got := permissionCodes(t, db); len(got) != 4 {
+				t.Errorf("Sync() dry run left permissions %q, want the 4 stored", got)
+			}
+
+			opts.DryRun = false
+			diff, err = manifest.Sync(db, file, opts)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if got := changes(diff); !reflect.DeepEqual(got, tt.changes) {
+				t.Errorf("Sync() changes = %q, want %q", got, tt.changes)
+			}
+			if got := permissionCodes(t, db); !reflect.DeepEqual(got, tt.permissions) {
+				t.Errorf("permissions = %q, want %q", got, tt.permissions)
+			}
+			var roles []string
+			db.Model(&model.Role{}).Order("name").Pluck("name", &roles)
+			if !reflect.DeepEqual(roles, tt.roles) {
+				t.Errorf("roles = %q, want %q", roles, tt.roles)
+			}
+
+			// A second sync has nothing left to do.
+			diff, err = manifest.Sync(db, file, opts)
+			if err != nil {
+				t.Fatalf("Sync() again error = %v", err)
+			}
+			if len(diff.Changes) != 0 {
+				t.Errorf("Sync() again changes = %q, want none", changes(diff))
+			}
+		})
+	}
+}
+
+func TestSyncGrants(t *testing.T) {
+	db := openDB(t)
+	file, _ := manifest.Parse([]byte(policyFile), "yaml")
+	if _, err := manifest.Sync(db, file, manifest.Options{Mode: manifest.ModeUpdate}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	var auditor model.Role
+	if err := db.Preload("Permissions").Preload("Grants").First(&auditor, "name = ?", "auditor").Error; err != nil {
+		t.Fatal(err)
+	}
+	grants := map[string]model.Effect{}
+	for _, p := range auditor.Permissions {
+		grants[p.Code] = auditor.EffectOf(p.ID)
+	}
+	want := map[string]model.Effect{"view_invoice": model.EffectAllow, "pay_invoice": model.EffectDeny}
+	if !reflect.DeepEqual(grants, want) {
+		t.Errorf("auditor grants = %v, want %v", grants, want)
+	}
+
+	unknown, _ := manifest.Parse([]byte("roles:\n  - name: clerk\n    permissions: [missing]\n"), "yaml")
+	if _, err := manifest.Sync(db, unknown, manifest.Options{}); err == nil || !strings.Contains(err.Error(), "unknown permission missing") {
+		t.Errorf("Sync() with an unknown permission error = %v", err)
+	}
+}
+
+func TestSyncPruneReferencedRoles(t *testing.T) {
+	db := openDB(t)
+	var stale model.Role
+	db.First(&stale, "name = ?", "stale")
+	requested := model.Role{Name: "requested", Active: true, ManagedBy: model.ManagedByPolicyFile}
+	user := model.User{FirstName: "Ana", Username: "ana", Email: "ana@example.com", Password: "x", Phone1: "+5511999999999"}
+	for _, v := range []any{&requested, &user} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, v := range []any{
+		&model.Group{Name: "finance", Active: true, Users: []model.User{user}, Roles: []model.Role{stale}},
+		&model.Invitation{Email: "bob@example.com", Roles: []model.Role{stale}, InviterID: user.ID, ExpiresAt: time.Now().Add(time.Hour), Nonce: "n"},
+		&model.AccessRequest{UserID: user.ID, RoleID: &stale.ID, Justification: "audit", Status: model.AccessRequestApproved},
+		&model.AccessRequest{UserID: user.ID, RoleID: &requested.ID, Justification: "audit"},
+	} {
+		if err := db.Omit("Users.*", "Roles.*").Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, _ := manifest.Parse([]byte(policyFile), "yaml")
+	diff, err := manifest.Sync(db, file, manifest.Options{Mode: manifest.ModePrune})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	got := changes(diff)
+	for _, want := range []string{"delete role stale", "skip role requested"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("Sync() changes = %q, want %q", got, want)
+		}
+	}
+	for _, table := range []string{"groups_roles", "invitations_roles"} {
+		var n int64
+		db.Table(table).Where("role_id = ?", stale.ID).Count(&n)
+		if n != 0 {
+			t.Errorf("%s has %d rows of the pruned role", table, n)
+		}
+	}
+	var left int64
+	db.Model(&model.AccessRequest{}).Where("role_id = ?", stale.ID).Count(&left)
+	if left != 0 {
+		t.Errorf("%d access requests still reference the pruned role", left)
+	}
+	// The pending request keeps its role.
+	if err := db.First(&model.Role{}, "name = ?", "requested").Error; err != nil {
+		t.Errorf("role requested = %v, want kept", err)
+	}
+}
+
+func TestSyncPolicyFileDryRun(t *testing.T) {
+	db := migratedDB(t)
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(policyFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := auth.SyncPolicyFile(db, path, manifest.ModePrune, true)
+	if err != nil {
+		t.Fatalf("SyncPolicyFile() error = %v", err)
+	}
+	if !diff.DryRun || !slices.Contains(changes(diff), "create role billing") {
+		t.Errorf("SyncPolicyFile() = %q, want a dry run creating billing", changes(diff))
+	}
+	// Neither the builtin permissions nor the file's are left behind.
+	if got := permissionCodes(t, db); len(got) != 0 {
+		t.Errorf("SyncPolicyFile() dry run left permissions %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		wantErr string
+	}{
+		{"yaml", policyFile, "yml", ""},
+		{"json", `{"permissions": [{"code": "view_invoice"}], "roles": [{"name": "clerk", "permissions": ["view_invoice"]}]}`, "json", ""},
+		{"unsupported format", policyFile, "toml", "unsupported"},
+		{"invalid yaml", "permissions: [", "yaml", "invalid policy file"},
+		{"permission without code", "permissions:\n  - description: x\n", "yaml", "code is required"},
+		{"permission declared twice", "permissions:\n  - code: a\n  - code: a\n", "yaml", "declared twice"},
+		{"admin deactivated", "permissions:\n  - code: admin\n    active: false\n", "yaml", "can't be deactivated"},
+		{"role without name", "roles:\n  - description: x\n", "yaml", "name is required"},
+		{"role declared twice", "roles:\n  - name: a\n  - name: a\n", "yaml", "declared twice"},
+		{"allowed and denied", "roles:\n  - name: a\n    permissions: [x]\n    deny: [x]\n", "yaml", "both allows and denies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manifest.Parse([]byte(tt.data), tt.format)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func changes(diff *manifest.Diff) []string {
+	var res []string
+	for _, c := range diff.Changes {
+		res = append(res, c.Action+" "+c.Kind+" "+c.Name)
+	}
+	return res
+}
+
+func permissionCodes(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var codes []string
+	if err := db.Model(&model.Permission{}).Order("code").Pluck("code", &codes).Error; err != nil {
+		t.Fatal(err)
+	}
+	return codes
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-gorote/auth/dto"
+	"github.com/google/uuid"
+)
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+)
+
+// AccessRequest is a user's request for a role or a tenant. Approving it
+// grants the assignment for the requested duration, or permanently when no
+// duration was given.
+type AccessRequest struct {
+	BaseModel
+	UserID        uuid.UUID           `gorm:"type:uuid;index;not null" json:"user_id"`
+	User          User                `json:"-"`
+	RoleID        *uuid.UUID          `gorm:"type:uuid" json:"role_id"`
+	Role          *Role               `json:"-"`
+	TenantID      *uuid.UUID          `gorm:"type:uuid" json:"tenant_id"`
+	Tenant        *Tenant             `json:"-"`
+	Justification string              `gorm:"size:500;not null" json:"justification"`
+	Duration      time.Duration       `json:"duration"`
+	Status        AccessRequestStatus `gorm:"size:20;index;default:pending" json:"status"`
+	ReviewerID    *uuid.UUID          `gorm:"type:uuid" json:"reviewer_id"`
+	ReviewedAt    *time.Time          `json:"reviewed_at"`
+	ReviewNote    string              `gorm:"size:500" json:"review_note"`
+}
+
+func (a AccessRequest) ToAccessRequestDto() dto.AccessRequestDto {
+	res := dto.AccessRequestDto{
+		ID:            a.ID.String(),
+		CreatedAt:     a.CreatedAt.Format("02/01/2006 15:04:05"),
+		UserID:        a.UserID.String(),
+		Username:      a.User.Username,
+		Justification: a.Justification,
+		Status:        string(a.Status),
+		ReviewNote:    a.ReviewNote,
+	}
+	if a.Duration > 0 {
+		res.Duration = a.Duration.String()
+	}
+	if a.RoleID != nil {
+		res.RoleID = a.RoleID.String()
+	}
+	if a.Role != nil {
+		res.RoleName = a.Role.Name
+	}
+	if a.TenantID != nil {
+		res.TenantID = a.TenantID.String()
+	}
+	if a.Tenant != nil {
+		res.TenantName = a.Tenant.Name
+	}
+	if a.ReviewerID != nil {
+		res.ReviewerID = a.ReviewerID.String()
+	}
+	if a.ReviewedAt != nil {
+		res.ReviewedAt = a.ReviewedAt.Format("02/01/2006 15:04:05")
+	}
+	return res
+}
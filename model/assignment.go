@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-gorote/auth/dto"
+	"github.com/google/uuid"
+)
+
+// UserRole is the users_roles join row. A nil StartsAt or ExpiresAt leaves
+// that side of the assignment window open.
+type UserRole struct {
+	UserID    uuid.UUID  `gorm:"primaryKey" json:"user_id"`
+	RoleID    uuid.UUID  `gorm:"primaryKey" json:"role_id"`
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+}
+
+func (UserRole) TableName() string {
+	return "users_roles"
+}
+
+func (a UserRole) ActiveAt(t time.Time) bool {
+	return activeAt(a.StartsAt, a.ExpiresAt, t)
+}
+
+// UserTenant is the users_tenants join row, with the same window semantics
+// as UserRole.
+type UserTenant struct {
+	UserID    uuid.UUID  `gorm:"primaryKey" json:"user_id"`
+	TenantID  uuid.UUID  `gorm:"primaryKey" json:"tenant_id"`
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+}
+
+func (UserTenant) TableName() string {
+	return "users_tenants"
+}
+
+func (a UserTenant) ActiveAt(t time.Time) bool {
+	return activeAt(a.StartsAt, a.ExpiresAt, t)
+}
+
+func activeAt(startsAt, expiresAt *time.Time, t time.Time) bool {
+	if startsAt != nil && t.Before(*startsAt) {
+		return false
+	}
+	if expiresAt != nil && !t.Before(*expiresAt) {
+		return false
+	}
+	return true
+}
+
+func toAssignmentDto(id uuid.UUID, name string, startsAt, expiresAt *time.Time, now time.Time) dto.AssignmentDto {
+	a := dto.AssignmentDto{
+		ID:     id.String(),
+		Name:   name,
+		Active: activeAt(startsAt, expiresAt, now),
+	}
+	if startsAt != nil {
+		a.StartsAt = startsAt.Format("02/01/2006 15:04:05")
+	}
+	if expiresAt != nil {
+		a.ExpiresAt = expiresAt.Format("02/01/2006 15:04:05")
+	}
+	return a
+}
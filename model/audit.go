@@ -0,0 +1,19 @@
+package model
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeDenied  = "denied"
+)
+
+// AuditEvent records a security relevant action and its outcome. ActorID is
+// empty for actions taken by the system.
+type AuditEvent struct {
+	BaseModel
+	ActorID    string         `gorm:"size:36;index" json:"actor_id"`
+	Action     string         `gorm:"size:50;index;not null" json:"action"`
+	TargetType string         `gorm:"size:50" json:"target_type"`
+	TargetID   string         `gorm:"size:100;index" json:"target_id"`
+	Outcome    string         `gorm:"size:20;not null" json:"outcome"`
+	Reason     string         `json:"reason"`
+	Details    map[string]any `gorm:"serializer:json" json:"details"`
+}
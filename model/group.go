@@ -0,0 +1,49 @@
+package model
+
+import "github.com/go-gorote/auth/dto"
+
+// Group bundles users so that roles and tenants can be assigned once for
+// all of its members.
+type Group struct {
+	BaseModel
+	Name        string   `gorm:"uniqueIndex;size:100;not null" json:"name"`
+	Description string   `json:"description"`
+	Users       []User   `gorm:"many2many:groups_users" json:"users"`
+	Roles       []Role   `gorm:"many2many:groups_roles" json:"roles"`
+	Tenants     []Tenant `gorm:"many2many:groups_tenants" json:"tenants"`
+	Active      bool     `json:"active"`
+}
+
+// TableName avoids GROUPS, which is reserved in MySQL.
+func (Group) TableName() string {
+	return "user_groups"
+}
+
+func (g Group) ToGroupDto() dto.GroupDto {
+	members := []dto.GroupMemberDto{}
+	for _, user := range g.Users {
+		members = append(members, dto.GroupMemberDto{
+			ID:       user.ID.String(),
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	}
+	roles := []dto.RoleDto{}
+	for _, role := range g.Roles {
+		roles = append(roles, role.ToRoleDto())
+	}
+	tenants := []dto.TenantDto{}
+	for _, tenant := range g.Tenants {
+		tenants = append(tenants, tenant.ToTenantDto())
+	}
+	return dto.GroupDto{
+		ID:          g.ID.String(),
+		UpdatedAt:   g.UpdatedAt.Format("02/01/2006 15:04:05"),
+		Name:        g.Name,
+		Description: g.Description,
+		Members:     members,
+		Roles:       roles,
+		Tenants:     tenants,
+		Active:      g.Active,
+	}
+}
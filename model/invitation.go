@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"github.com/go-gorote/auth/dto"
+	"github.com/google/uuid"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	// InvitationExpired is never stored: it is a pending invitation past
+	// its ExpiresAt.
+	InvitationExpired InvitationStatus = "expired"
+)
+
+// Invitation asks the owner of Email to join with the given roles and
+// tenants. The invitee sets their own profile and password when accepting
+// the link sent to them. Nonce changes with every link sent, so that only
+// the last one works.
+type Invitation struct {
+	BaseModel
+	Email      string           `gorm:"size:255;index;not null" json:"email"`
+	Roles      []Role           `gorm:"many2many:invitations_roles" json:"roles"`
+	Tenants    []Tenant         `gorm:"many2many:invitations_tenants" json:"tenants"`
+	InviterID  uuid.UUID        `gorm:"type:uuid;index;not null" json:"inviter_id"`
+	Inviter    User             `json:"-"`
+	ExpiresAt  time.Time        `gorm:"not null" json:"expires_at"`
+	Status     InvitationStatus `gorm:"size:20;index;default:pending" json:"status"`
+	Nonce      string           `gorm:"size:36;not null" json:"-"`
+	SentAt     time.Time        `json:"sent_at"`
+	UserID     *uuid.UUID       `gorm:"type:uuid" json:"user_id"`
+	AcceptedAt *time.Time       `json:"accepted_at"`
+}
+
+// StatusAt returns the status of the invitation at t, telling expired
+// invitations apart from pending ones.
+func (i Invitation) StatusAt(t time.Time) InvitationStatus {
+	if i.Status == InvitationPending && !t.Before(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
+
+func (i Invitation) ToInvitationDto() dto.InvitationDto {
+	roles := []dto.RoleDto{}
+	for _, role := range i.Roles {
+		roles = append(roles, role.ToRoleDto())
+	}
+	tenants := []dto.TenantDto{}
+	for _, tenant := range i.Tenants {
+		tenants = append(tenants, tenant.ToTenantDto())
+	}
+	res := dto.InvitationDto{
+		ID:              i.ID.String(),
+		CreatedAt:       i.CreatedAt.Format("02/01/2006 15:04:05"),
+		Email:           i.Email,
+		Roles:           roles,
+		Tenants:         tenants,
+		InviterID:       i.InviterID.String(),
+		InviterUsername: i.Inviter.Username,
+		Status:          string(i.StatusAt(time.Now())),
+		ExpiresAt:       i.ExpiresAt.Format("02/01/2006 15:04:05"),
+		SentAt:          i.SentAt.Format("02/01/2006 15:04:05"),
+	}
+	if i.UserID != nil {
+		res.UserID = i.UserID.String()
+	}
+	if i.AcceptedAt != nil {
+		res.AcceptedAt = i.AcceptedAt.Format("02/01/2006 15:04:05")
+	}
+	return res
+}
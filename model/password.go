@@ -0,0 +1,11 @@
+package model
+
+import "github.com/google/uuid"
+
+// PasswordHistory keeps the hash of a password the user replaced, so that
+// it can't be reused while the policy remembers it.
+type PasswordHistory struct {
+	BaseModel
+	UserID uuid.UUID `gorm:"type:uuid;index;not null"`
+	Hash   string    `gorm:"not null"`
+}
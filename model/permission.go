@@ -2,11 +2,18 @@ package model
 
 import "github.com/go-gorote/auth/dto"
 
+const (
+	ManagedByUser       = "user"
+	ManagedByPolicyFile = "policy_file"
+)
+
 type Permission struct {
 	BaseModel
 	Code        string `gorm:"uniqueIndex;size:50;not null" validate:"required,regexp=^[a-zA-Z0-9_]+$" json:"code"`
 	Description string `json:"description"`
+	Group       string `gorm:"size:50;index" json:"group"`
 	Active      bool   `json:"active"`
+	ManagedBy   string `gorm:"size:20" json:"managed_by"`
 }
 
 func (p Permission) ToPermissionDto() dto.PermissionDto {
@@ -15,6 +22,14 @@ func (p Permission) ToPermissionDto() dto.PermissionDto {
 		UpdatedAt:   p.UpdatedAt.Format("02/01/2006 15:04:05"),
 		Code:        p.Code,
 		Description: p.Description,
+		Group:       p.Group,
 		Active:      p.Active,
+		ManagedBy:   p.ManagedBy,
 	}
 }
+
+// UserManaged reports whether the permission was created outside of the
+// policy file. Policy file sync never deletes user managed records.
+func (p Permission) UserManaged() bool {
+	return p.ManagedBy != ManagedByPolicyFile
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/policy"
+)
+
+type Policy struct {
+	BaseModel
+	Name        string             `gorm:"uniqueIndex;size:100;not null" validate:"required,min=3,max=100,regexp=^[a-zA-Z0-9_]+$" json:"name"`
+	Description string             `json:"description"`
+	Effect      policy.Effect      `gorm:"size:10;not null;default:allow" json:"effect"`
+	Actions     []string           `gorm:"serializer:json" json:"actions"`
+	Resource    string             `gorm:"size:100;not null" json:"resource"`
+	Conditions  []policy.Condition `gorm:"serializer:json" json:"conditions"`
+	Active      bool               `json:"active"`
+}
+
+func (p Policy) ToRule() policy.Rule {
+	return policy.Rule{
+		Name:       p.Name,
+		Effect:     p.Effect,
+		Actions:    p.Actions,
+		Resource:   p.Resource,
+		Conditions: p.Conditions,
+	}
+}
+
+func (p Policy) ToPolicyDto() dto.PolicyDto {
+	conditions := []dto.ConditionDto{}
+	for _, c := range p.Conditions {
+		conditions = append(conditions, dto.ConditionDto{
+			Attribute: c.Attribute,
+			Operator:  string(c.Operator),
+			Value:     c.Value,
+			Ref:       c.Ref,
+		})
+	}
+	return dto.PolicyDto{
+		ID:          p.ID.String(),
+		UpdatedAt:   p.UpdatedAt.Format("02/01/2006 15:04:05"),
+		Name:        p.Name,
+		Description: p.Description,
+		Effect:      string(p.Effect),
+		Actions:     p.Actions,
+		Resource:    p.Resource,
+		Conditions:  conditions,
+		Active:      p.Active,
+	}
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/go-gorote/auth/dto"
+)
+
+// PermissionSource tells through which path a user holds a permission.
+type PermissionSource string
+
+const (
+	// SourceDirect is a tenant assigned to the user.
+	SourceDirect    PermissionSource = "direct"
+	SourceRole      PermissionSource = "role"
+	SourceGroup     PermissionSource = "group"
+	SourceSuperUser PermissionSource = "super_user"
+)
+
+// Reasons a grant is not in effect.
+const (
+	FilteredGroupInactive      = "group_inactive"
+	FilteredAssignmentPending  = "assignment_pending"
+	FilteredAssignmentExpired  = "assignment_expired"
+	FilteredRoleInactive       = "role_inactive"
+	FilteredPermissionInactive = "permission_inactive"
+	FilteredTenantInactive     = "tenant_inactive"
+	FilteredDenied             = "denied"
+)
+
+// PermissionGrant is one path through which a user holds a permission.
+// Filtered holds the reason the grant is not in effect, if any.
+type PermissionGrant struct {
+	Code     string
+	Effect   Effect
+	Source   PermissionSource
+	Role     string
+	Group    string
+	Filtered string
+}
+
+// PermissionGrants lists every permission the user's roles link to at t,
+// directly or through a group, including the ones filtered out by inactive
+// groups, roles, permissions or assignments. Allows overridden by a deny are
+// filtered as denied, matching EffectivePermissions.
+func (u *User) PermissionGrants(t time.Time) []PermissionGrant {
+	var grants []PermissionGrant
+	add := func(role Role, source PermissionSource, group, filtered string) {
+		if filtered == "" && !role.Active {
+			filtered = FilteredRoleInactive
+		}
+		for _, p := range role.Permissions {
+			grant := PermissionGrant{
+				Code:     p.Code,
+				Effect:   role.EffectOf(p.ID),
+				Source:   source,
+				Role:     role.Name,
+				Group:    group,
+				Filtered: filtered,
+			}
+			if grant.Filtered == "" && !p.Active {
+				grant.Filtered = FilteredPermissionInactive
+			}
+			grants = append(grants, grant)
+		}
+	}
+	for _, role := range u.Roles {
+		filtered := ""
+		if a := u.roleAssignment(role.ID); !a.ActiveAt(t) {
+			filtered = FilteredAssignmentExpired
+			if a.StartsAt != nil && t.Before(*a.StartsAt) {
+				filtered = FilteredAssignmentPending
+			}
+		}
+		add(role, SourceRole, "", filtered)
+	}
+	for _, group := range u.Groups {
+		filtered := ""
+		if !group.Active {
+			filtered = FilteredGroupInactive
+		}
+		for _, role := range group.Roles {
+			add(role, SourceGroup, group.Name, filtered)
+		}
+	}
+
+	var denied []string
+	for _, g := range grants {
+		if g.Filtered == "" && g.Effect == EffectDeny {
+			denied = append(denied, g.Code)
+		}
+	}
+	for i, g := range grants {
+		if g.Filtered == "" && g.Effect == EffectAllow && slices.Contains(denied, g.Code) {
+			grants[i].Filtered = FilteredDenied
+		}
+	}
+	return grants
+}
+
+// TenantGrant is one path through which a user is assigned a tenant.
+// Filtered holds the reason the tenant is not in effect, if any.
+type TenantGrant struct {
+	Name     string
+	Source   PermissionSource
+	Group    string
+	Filtered string
+}
+
+// TenantGrants lists every tenant assigned to the user at t, directly or
+// through a group, including the ones filtered out by inactive tenants,
+// groups or assignments.
+func (u *User) TenantGrants(t time.Time) []TenantGrant {
+	var grants []TenantGrant
+	for _, tenant := range u.Tenants {
+		grant := TenantGrant{Name: tenant.Name, Source: SourceDirect}
+		if a := u.tenantAssignment(tenant.ID); !a.ActiveAt(t) {
+			grant.Filtered = FilteredAssignmentExpired
+			if a.StartsAt != nil && t.Before(*a.StartsAt) {
+				grant.Filtered = FilteredAssignmentPending
+			}
+		} else if !tenant.Active {
+			grant.Filtered = FilteredTenantInactive
+		}
+		grants = append(grants, grant)
+	}
+	for _, group := range u.Groups {
+		for _, tenant := range group.Tenants {
+			grant := TenantGrant{Name: tenant.Name, Source: SourceGroup, Group: group.Name}
+			if !group.Active {
+				grant.Filtered = FilteredGroupInactive
+			} else if !tenant.Active {
+				grant.Filtered = FilteredTenantInactive
+			}
+			grants = append(grants, grant)
+		}
+	}
+	return grants
+}
+
+// ToEffectivePermissionsDto splits the grants into the ones in effect and the
+// filtered ones. Permissions apply in every tenant active at t.
+func (u *User) ToEffectivePermissionsDto(grants []PermissionGrant, t time.Time) dto.EffectivePermissionsDto {
+	tenants := []string{}
+	for _, tenant := range u.ActiveTenants(t) {
+		tenants = append(tenants, tenant.Name)
+	}
+	allowed, denied := u.EffectivePermissions()
+	res := dto.EffectivePermissionsDto{
+		UserID:      u.ID.String(),
+		IsSuperUser: u.IsSuperUser,
+		Allowed:     append([]string{}, allowed...),
+		Denied:      append([]string{}, denied...),
+		Tenants:     tenants,
+		Grants:      []dto.PermissionGrantDto{},
+		Filtered:    []dto.PermissionGrantDto{},
+
+		FilteredTenants: []dto.TenantGrantDto{},
+	}
+	for _, g := range u.TenantGrants(t) {
+		if g.Filtered != "" {
+			res.FilteredTenants = append(res.FilteredTenants, dto.TenantGrantDto{
+				Name:   g.Name,
+				Source: string(g.Source),
+				Group:  g.Group,
+				Reason: g.Filtered,
+			})
+		}
+	}
+	for _, g := range grants {
+		grant := dto.PermissionGrantDto{
+			Code:    g.Code,
+			Effect:  string(g.Effect),
+			Source:  string(g.Source),
+			Role:    g.Role,
+			Group:   g.Group,
+			Tenants: tenants,
+			Reason:  g.Filtered,
+		}
+		if g.Filtered == "" {
+			res.Grants = append(res.Grants, grant)
+		} else {
+			res.Filtered = append(res.Filtered, grant)
+		}
+	}
+	return res
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/rebac"
+)
+
+type RelationTuple struct {
+	BaseModel
+	Namespace       string `gorm:"uniqueIndex:idx_relation_tuple;index:idx_relation_object;size:100;not null" json:"namespace"`
+	ObjectID        string `gorm:"uniqueIndex:idx_relation_tuple;index:idx_relation_object;size:100;not null" json:"object_id"`
+	Relation        string `gorm:"uniqueIndex:idx_relation_tuple;index:idx_relation_object;size:100;not null" json:"relation"`
+	SubjectType     string `gorm:"uniqueIndex:idx_relation_tuple;size:100;not null" json:"subject_type"`
+	SubjectID       string `gorm:"uniqueIndex:idx_relation_tuple;size:100;not null" json:"subject_id"`
+	SubjectRelation string `gorm:"uniqueIndex:idx_relation_tuple;size:100" json:"subject_relation"`
+}
+
+func (t RelationTuple) ToTuple() rebac.Tuple {
+	return rebac.Tuple{
+		Namespace: t.Namespace,
+		ObjectID:  t.ObjectID,
+		Relation:  t.Relation,
+		Subject: rebac.Subject{
+			Type:     t.SubjectType,
+			ID:       t.SubjectID,
+			Relation: t.SubjectRelation,
+		},
+	}
+}
+
+func (t RelationTuple) ToRelationTupleDto() dto.RelationTupleDto {
+	return dto.RelationTupleDto{
+		ID:              t.ID.String(),
+		UpdatedAt:       t.UpdatedAt.Format("02/01/2006 15:04:05"),
+		Namespace:       t.Namespace,
+		ObjectID:        t.ObjectID,
+		Relation:        t.Relation,
+		SubjectType:     t.SubjectType,
+		SubjectID:       t.SubjectID,
+		SubjectRelation: t.SubjectRelation,
+		Tuple:           t.ToTuple().String(),
+	}
+}
@@ -1,20 +1,56 @@
 package model
 
-import "github.com/go-gorote/auth/dto"
+import (
+	"github.com/go-gorote/auth/dto"
+	"github.com/google/uuid"
+)
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
 
 type Role struct {
 	BaseModel
-	Name        string       `gorm:"uniqueIndex;size:100;not null" validate:"required,min=3,max=100,regexp=^[a-zA-Z0-9_]+$" json:"name"`
-	Description string       `json:"description"`
-	Permissions []Permission `gorm:"many2many:roles_permissions" json:"permissions"`
-	Active      bool         `json:"active"`
+	Name        string           `gorm:"uniqueIndex;size:100;not null" validate:"required,min=3,max=100,regexp=^[a-zA-Z0-9_]+$" json:"name"`
+	Description string           `json:"description"`
+	Permissions []Permission     `gorm:"many2many:roles_permissions" json:"permissions"`
+	Grants      []RolePermission `gorm:"foreignKey:RoleID" json:"-"`
+	Active      bool             `json:"active"`
+	ManagedBy   string           `gorm:"size:20" json:"managed_by"`
+}
+
+// RolePermission is the join row between a role and a permission. Effect
+// marks the link as a grant or as an explicit deny.
+type RolePermission struct {
+	RoleID       uuid.UUID `gorm:"primaryKey"`
+	PermissionID uuid.UUID `gorm:"primaryKey"`
+	Effect       Effect    `gorm:"size:10;not null;default:allow"`
+}
+
+func (RolePermission) TableName() string {
+	return "roles_permissions"
+}
+
+// EffectOf returns the effect of the link between the role and the permission.
+func (r *Role) EffectOf(permissionID uuid.UUID) Effect {
+	for _, g := range r.Grants {
+		if g.PermissionID == permissionID && g.Effect == EffectDeny {
+			return EffectDeny
+		}
+	}
+	return EffectAllow
 }
 
 func (r *Role) ToRoleDto() dto.RoleDto {
 	permissions := []dto.PermissionDto{}
 
 	for _, p := range r.Permissions {
-		permissions = append(permissions, p.ToPermissionDto())
+		permission := p.ToPermissionDto()
+		permission.Effect = string(r.EffectOf(p.ID))
+		permissions = append(permissions, permission)
 	}
 	return dto.RoleDto{
 		ID:          r.ID.String(),
@@ -23,5 +59,12 @@ func (r *Role) ToRoleDto() dto.RoleDto {
 		Description: r.Description,
 		Permissions: permissions,
 		Active:      r.Active,
+		ManagedBy:   r.ManagedBy,
 	}
 }
+
+// UserManaged reports whether the role was created outside of the policy
+// file. Policy file sync never deletes user managed records.
+func (r *Role) UserManaged() bool {
+	return r.ManagedBy != ManagedByPolicyFile
+}
@@ -1,6 +1,51 @@
 package model
 
-import "github.com/go-gorote/auth/dto"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-gorote/auth/dto"
+	"github.com/go-gorote/auth/policy"
+	"github.com/go-gorote/auth/secret"
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// UserStatus is the state of an account in its lifecycle. Only active
+// users can log in.
+type UserStatus string
+
+const (
+	// UserPending accounts wait for activation, e.g. to verify their email.
+	UserPending UserStatus = "pending"
+	UserActive  UserStatus = "active"
+	// UserSuspended accounts are disabled for a reason, until a time when
+	// one is set.
+	UserSuspended UserStatus = "suspended"
+	// UserLocked accounts are disabled for security, e.g. a compromise,
+	// until an admin unlocks them.
+	UserLocked UserStatus = "locked"
+	// UserDeprovisioned accounts were removed from the organization and
+	// can't come back.
+	UserDeprovisioned UserStatus = "deprovisioned"
+)
+
+// userTransitions are the edges of the lifecycle.
+var userTransitions = map[UserStatus][]UserStatus{
+	UserPending:       {UserActive, UserDeprovisioned},
+	UserActive:        {UserSuspended, UserLocked, UserDeprovisioned},
+	UserSuspended:     {UserActive, UserLocked, UserDeprovisioned},
+	UserLocked:        {UserActive, UserDeprovisioned},
+	UserDeprovisioned: {},
+}
+
+// CanTransition reports whether an account may go from s to status.
+func (s UserStatus) CanTransition(status UserStatus) bool {
+	return slices.Contains(userTransitions[s], status)
+}
 
 type User struct {
 	BaseModel
@@ -15,7 +60,26 @@ type User struct {
 	Roles       []Role   `gorm:"many2many:users_roles" json:"roles"`
 	Tenants     []Tenant `gorm:"many2many:users_tenants" json:"tenants"`
 	Avatar      string   `json:"avatar"`
-	Active      bool     `gorm:"default:true" json:"active"`
+	Locale      string   `gorm:"size:35" json:"locale"`
+	// PasswordChangedAt is when the password was last set. Users created
+	// before it was tracked count from CreatedAt.
+	PasswordChangedAt *time.Time `json:"-"`
+	// MustChangePassword restricts the user's logins to changing their
+	// password, e.g. after an admin set a temporary one.
+	MustChangePassword bool `gorm:"not null;default:false" json:"must_change_password"`
+	// Active mirrors Status == UserActive for the queries and clients
+	// predating Status.
+	Active bool       `gorm:"default:true" json:"active"`
+	Status UserStatus `gorm:"size:20;not null;default:active;index" json:"status"`
+	// SuspendedReason and SuspendedUntil describe a suspension. The user is
+	// active again from SuspendedUntil, when set.
+	SuspendedReason string     `gorm:"size:500" json:"suspended_reason,omitempty"`
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+
+	RoleAssignments   []UserRole   `gorm:"foreignKey:UserID" json:"-"`
+	TenantAssignments []UserTenant `gorm:"foreignKey:UserID" json:"-"`
+	Groups            []Group      `gorm:"many2many:groups_users" json:"groups"`
 }
 
 func (u *User) ToUserDto() dto.UserDto {
@@ -27,7 +91,22 @@ func (u *User) ToUserDto() dto.UserDto {
 	for _, tenant := range u.Tenants {
 		tenants = append(tenants, tenant.ToTenantDto())
 	}
-	return dto.UserDto{
+	now := time.Now()
+	roleAssignments := []dto.AssignmentDto{}
+	for _, role := range u.Roles {
+		a := u.roleAssignment(role.ID)
+		roleAssignments = append(roleAssignments, toAssignmentDto(role.ID, role.Name, a.StartsAt, a.ExpiresAt, now))
+	}
+	tenantAssignments := []dto.AssignmentDto{}
+	for _, tenant := range u.Tenants {
+		a := u.tenantAssignment(tenant.ID)
+		tenantAssignments = append(tenantAssignments, toAssignmentDto(tenant.ID, tenant.Name, a.StartsAt, a.ExpiresAt, now))
+	}
+	groups := []dto.GroupRefDto{}
+	for _, group := range u.Groups {
+		groups = append(groups, dto.GroupRefDto{ID: group.ID.String(), Name: group.Name})
+	}
+	res := dto.UserDto{
 		ID:          u.ID.String(),
 		UpdatedAt:   u.UpdatedAt.Format("02/01/2006 15:04:05"),
 		FirstName:   u.FirstName,
@@ -40,6 +119,273 @@ func (u *User) ToUserDto() dto.UserDto {
 		Roles:       roles,
 		Tenants:     tenants,
 		Avatar:      u.Avatar,
+		Locale:      u.Locale,
 		Active:      u.Active,
+		Status:      string(u.Status),
+
+		SuspendedReason:    u.SuspendedReason,
+		MustChangePassword: u.MustChangePassword,
+
+		RoleAssignments:   roleAssignments,
+		TenantAssignments: tenantAssignments,
+		Groups:            groups,
+	}
+	if u.SuspendedUntil != nil {
+		res.SuspendedUntil = u.SuspendedUntil.Format("02/01/2006 15:04:05")
+	}
+	return res
+}
+
+// ToMeDto returns the profile of the user together with the tenants and
+// permissions a token issued now would carry.
+func (u *User) ToMeDto() dto.MeDto {
+	tenants := []string{}
+	for _, tenant := range u.ActiveTenants(time.Now()) {
+		tenants = append(tenants, tenant.Name)
+	}
+	allowed, denied := u.EffectivePermissions()
+	return dto.MeDto{
+		User:        u.ToUserDto(),
+		Tenants:     tenants,
+		Permissions: append([]string{}, allowed...),
+		Denied:      append([]string{}, denied...),
+	}
+}
+
+// PasswordSetAt returns when the current password was set.
+func (u *User) PasswordSetAt() time.Time {
+	if u.PasswordChangedAt != nil {
+		return *u.PasswordChangedAt
+	}
+	return u.CreatedAt
+}
+
+// roleAssignment returns the join row of the role. Roles loaded without
+// their assignments are treated as permanent.
+func (u *User) roleAssignment(roleID uuid.UUID) UserRole {
+	for _, a := range u.RoleAssignments {
+		if a.RoleID == roleID {
+			return a
+		}
+	}
+	return UserRole{UserID: u.ID, RoleID: roleID}
+}
+
+func (u *User) tenantAssignment(tenantID uuid.UUID) UserTenant {
+	for _, a := range u.TenantAssignments {
+		if a.TenantID == tenantID {
+			return a
+		}
 	}
+	return UserTenant{UserID: u.ID, TenantID: tenantID}
+}
+
+// groupRoles returns the roles inherited from the user's active groups.
+func (u *User) groupRoles() []Role {
+	var roles []Role
+	for _, group := range u.Groups {
+		if !group.Active {
+			continue
+		}
+		for _, role := range group.Roles {
+			if !slices.ContainsFunc(roles, func(r Role) bool { return r.ID == role.ID }) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+func (u *User) groupTenants() []Tenant {
+	var tenants []Tenant
+	for _, group := range u.Groups {
+		if !group.Active {
+			continue
+		}
+		for _, tenant := range group.Tenants {
+			if !slices.ContainsFunc(tenants, func(t Tenant) bool { return t.ID == tenant.ID }) {
+				tenants = append(tenants, tenant)
+			}
+		}
+	}
+	return tenants
+}
+
+// AllRoles returns every role the user holds, directly or through a group,
+// whether or not it is currently in effect.
+func (u *User) AllRoles() []Role {
+	roles := slices.Clone(u.Roles)
+	for _, role := range u.groupRoles() {
+		if !slices.ContainsFunc(roles, func(r Role) bool { return r.ID == role.ID }) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// ActiveRoles returns the direct roles whose assignment is in effect at t,
+// followed by the roles inherited from active groups.
+func (u *User) ActiveRoles(t time.Time) []Role {
+	var roles []Role
+	for _, role := range u.Roles {
+		if u.roleAssignment(role.ID).ActiveAt(t) {
+			roles = append(roles, role)
+		}
+	}
+	for _, role := range u.groupRoles() {
+		if !slices.ContainsFunc(roles, func(r Role) bool { return r.ID == role.ID }) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// ActiveTenants returns the active direct tenants whose assignment is in
+// effect at t, followed by the active tenants inherited from active groups.
+func (u *User) ActiveTenants(t time.Time) []Tenant {
+	var tenants []Tenant
+	for _, tenant := range u.Tenants {
+		if tenant.Active && u.tenantAssignment(tenant.ID).ActiveAt(t) {
+			tenants = append(tenants, tenant)
+		}
+	}
+	for _, tenant := range u.groupTenants() {
+		if tenant.Active && !slices.ContainsFunc(tenants, func(x Tenant) bool { return x.ID == tenant.ID }) {
+			tenants = append(tenants, tenant)
+		}
+	}
+	return tenants
+}
+
+// AssignmentsExpireAt returns the earliest expiry among the role and tenant
+// assignments in effect at t, or nil when none of them expires.
+func (u *User) AssignmentsExpireAt(t time.Time) *time.Time {
+	var earliest *time.Time
+	keep := func(expiresAt *time.Time) {
+		if expiresAt != nil && (earliest == nil || expiresAt.Before(*earliest)) {
+			earliest = expiresAt
+		}
+	}
+	// Grants also inherited from a group don't expire with the assignment.
+	groupRoles, groupTenants := u.groupRoles(), u.groupTenants()
+	for _, role := range u.ActiveRoles(t) {
+		if !slices.ContainsFunc(groupRoles, func(r Role) bool { return r.ID == role.ID }) {
+			keep(u.roleAssignment(role.ID).ExpiresAt)
+		}
+	}
+	for _, tenant := range u.ActiveTenants(t) {
+		if !slices.ContainsFunc(groupTenants, func(x Tenant) bool { return x.ID == tenant.ID }) {
+			keep(u.tenantAssignment(tenant.ID).ExpiresAt)
+		}
+	}
+	return earliest
+}
+
+// EffectivePermissions resolves the permission codes granted by the user's
+// active roles, assignments and groups. A deny on any role overrides an
+// allow on every other role.
+func (u *User) EffectivePermissions() (allowed, denied []string) {
+	for _, role := range u.ActiveRoles(time.Now()) {
+		if !role.Active {
+			continue
+		}
+		for _, p := range role.Permissions {
+			if !p.Active {
+				continue
+			}
+			if role.EffectOf(p.ID) == EffectDeny {
+				if !slices.Contains(denied, p.Code) {
+					denied = append(denied, p.Code)
+				}
+			} else if !slices.Contains(allowed, p.Code) {
+				allowed = append(allowed, p.Code)
+			}
+		}
+	}
+	allowed = slices.DeleteFunc(allowed, func(code string) bool {
+		return slices.Contains(denied, code)
+	})
+	return allowed, denied
+}
+
+// Attributes exposes the user as a policy resource.
+func (u *User) Attributes() policy.Attributes {
+	tenants := []string{}
+	for _, tenant := range u.ActiveTenants(time.Now()) {
+		tenants = append(tenants, tenant.Name)
+	}
+	return policy.Attributes{
+		"id":            u.ID.String(),
+		"username":      u.Username,
+		"tenants":       tenants,
+		"is_super_user": u.IsSuperUser,
+		"active":        u.Active,
+		"status":        string(u.Status),
+	}
+}
+
+// StatusAt returns the status of the user at t, when a suspension may have
+// ended without the stored status being changed yet.
+func (u *User) StatusAt(t time.Time) UserStatus {
+	if u.Status == UserSuspended && u.SuspendedUntil != nil && !t.Before(*u.SuspendedUntil) {
+		return UserActive
+	}
+	return u.Status
+}
+
+// AccountError returns the error of a user who may not log in at t, one
+// of the secret.ErrAccount errors, or nil for active users. The reason of
+// a suspension is left out, since it is meant for admins.
+func (u *User) AccountError(t time.Time) error {
+	switch u.StatusAt(t) {
+	case UserActive:
+		return nil
+	case UserPending:
+		return secret.ErrAccountPending
+	case UserSuspended:
+		if u.SuspendedUntil != nil {
+			return fmt.Errorf("%w until %s", secret.ErrAccountSuspended, u.SuspendedUntil.Format("02/01/2006 15:04:05"))
+		}
+		return secret.ErrAccountSuspended
+	case UserLocked:
+		return secret.ErrAccountLocked
+	case UserDeprovisioned:
+		return secret.ErrAccountDeprovisioned
+	}
+	return fmt.Errorf("unknown account status %q", u.Status)
+}
+
+// BeforeCreate defaults the status to active and derives Active from it.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.Status == "" {
+		u.Status = UserActive
+	}
+	u.Active = u.Status == UserActive
+	return u.BaseModel.BeforeCreate(tx)
+}
+
+// AfterCreate stores a false Active, which Create replaces by the column
+// default.
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	if u.Status == UserActive {
+		return nil
+	}
+	u.Active = false
+	return tx.Model(u).UpdateColumn("active", false).Error
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in:
+// trimmed, NFKC normalized and lower-cased, so that addresses differing
+// only in case or Unicode representation belong to the same user.
+func NormalizeEmail(email string) string {
+	return normalizeIdentity(email)
+}
+
+// NormalizeUsername is NormalizeEmail for usernames.
+func NormalizeUsername(username string) string {
+	return normalizeIdentity(username)
+}
+
+func normalizeIdentity(s string) string {
+	return norm.NFKC.String(strings.ToLower(norm.NFKC.String(strings.TrimSpace(s))))
 }
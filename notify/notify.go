@@ -0,0 +1,64 @@
+// Package notify delivers messages about account events, such as access
+// requests waiting for review, to users through a channel chosen by the
+// application.
+package notify
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Kind identifies the event a message is about, so that a notifier can pick
+// a template.
+type Kind string
+
+// Recipient is a user a message is addressed to.
+type Recipient struct {
+	UserID string
+	Name   string
+	Email  string
+	Phone  string
+}
+
+type Message struct {
+	Kind    Kind
+	To      []Recipient
+	Subject string
+	Body    string
+	// Data carries the event fields, e.g. the id of the access request.
+	Data map[string]string
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, msg Message) error
+
+func (f NotifierFunc) Notify(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Log is the notifier used when the application configures none. It only
+// records the messages.
+type Log struct {
+	Logger *slog.Logger
+}
+
+func (l Log) Notify(ctx context.Context, msg Message) error {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, r := range msg.To {
+		to = append(to, r.Email)
+	}
+	logger.InfoContext(ctx, "notification",
+		"kind", msg.Kind,
+		"to", to,
+		"subject", msg.Subject,
+	)
+	return nil
+}
@@ -0,0 +1,252 @@
+package passwd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Corpus is a set of passwords known from breaches.
+type Corpus interface {
+	Contains(password string) (bool, error)
+	// Close releases the file the corpus is searched in, if any.
+	Close() error
+}
+
+// OpenCorpus opens a breached password corpus stored in a file, either a
+// bloom filter written by BloomFilter.WriteTo or a sorted hash file. Close
+// it once done.
+func OpenCorpus(path string) (Corpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+	magic := make([]byte, 2)
+	if _, err := f.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		f.Close()
+		return nil, fmt.Errorf("failed to read breached password corpus: %w", err)
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		defer f.Close()
+		return ReadBloomFilter(f)
+	}
+	return NewSortedHashFile(f)
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// SortedHashFile is a corpus made of the uppercase hex SHA-1 hashes of the
+// passwords, one per line and sorted, such as the "ordered by hash"
+// download of Have I Been Pwned. Anything after the hash on a line, like
+// HIBP's ":count", is ignored. The file is searched in place.
+type SortedHashFile struct {
+	r    io.ReaderAt
+	size int64
+}
+
+// NewSortedHashFile searches the file, which must stay open while in use.
+// Close closes it.
+func NewSortedHashFile(f *os.File) (*SortedHashFile, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read breached password corpus: %w", err)
+	}
+	return &SortedHashFile{r: f, size: info.Size()}, nil
+}
+
+// Close closes the file when it is an io.Closer.
+func (c *SortedHashFile) Close() error {
+	if closer, ok := c.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Contains binary searches the file for the hash of the password.
+func (c *SortedHashFile) Contains(password string) (bool, error) {
+	want := sha1Hex(password)
+	lo, hi := int64(0), c.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		next, line, err := c.lineAt(mid)
+		if errors.Is(err, io.EOF) {
+			hi = mid
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to search breached password corpus: %w", err)
+		}
+		hash := strings.ToUpper(line[:min(len(line), len(want))])
+		switch strings.Compare(hash, want) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineAt returns the first line starting at or after off, without its
+// line ending, and the offset of the line after it.
+func (c *SortedHashFile) lineAt(off int64) (int64, string, error) {
+	start := off
+	if off > 0 {
+		start = off - 1
+	}
+	r := bufio.NewReaderSize(io.NewSectionReader(c.r, start, c.size-start), 128)
+	if off > 0 {
+		skipped, err := r.ReadString('\n')
+		if err != nil {
+			return 0, "", io.EOF
+		}
+		start += int64(len(skipped))
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return 0, "", err
+	}
+	return start + int64(len(line)), strings.TrimRight(line, "\r\n"), nil
+}
+
+// bloomMagic starts a bloom filter file, after which come the number of
+// bits and hashes and the bits themselves, all gzipped.
+const bloomMagic = "PWBLOOM1"
+
+// BloomFilter is a compact corpus that may report a password as breached
+// when it isn't, at the rate it was built for, but never the opposite.
+type BloomFilter struct {
+	bits   []uint64
+	m      uint64
+	hashes uint32
+}
+
+// NewBloomFilter sizes a filter for n passwords with the false positive
+// rate p.
+func NewBloomFilter(n int, p float64) *BloomFilter {
+	m := uint64(math.Ceil(-float64(max(n, 1)) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	hashes := uint32(max(1, math.Round(float64(m)/float64(max(n, 1))*math.Ln2)))
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), m: m, hashes: hashes}
+}
+
+// Add adds the password to the filter.
+func (b *BloomFilter) Add(password string) {
+	sum := sha1.Sum([]byte(password))
+	b.add(sum)
+}
+
+// AddHash adds a password by the hex SHA-1 of it, as found in sorted hash
+// files.
+func (b *BloomFilter) AddHash(hash string) error {
+	var sum [sha1.Size]byte
+	if len(hash) < 2*sha1.Size {
+		return fmt.Errorf("invalid sha1 hash %q", hash)
+	}
+	if _, err := hex.Decode(sum[:], []byte(hash[:2*sha1.Size])); err != nil {
+		return fmt.Errorf("invalid sha1 hash %q", hash)
+	}
+	b.add(sum)
+	return nil
+}
+
+func (b *BloomFilter) add(sum [sha1.Size]byte) {
+	for _, bit := range b.positions(sum) {
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Close does nothing: the filter is held in memory.
+func (b *BloomFilter) Close() error {
+	return nil
+}
+
+func (b *BloomFilter) Contains(password string) (bool, error) {
+	for _, bit := range b.positions(sha1.Sum([]byte(password))) {
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// positions derives the bits of a password from two halves of its hash.
+func (b *BloomFilter) positions(sum [sha1.Size]byte) []uint64 {
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16])
+	positions := make([]uint64, b.hashes)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % b.m
+	}
+	return positions
+}
+
+// WriteTo writes the filter in the format read by ReadBloomFilter.
+func (b *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	zw := gzip.NewWriter(counter)
+	var header bytes.Buffer
+	header.WriteString(bloomMagic)
+	binary.Write(&header, binary.BigEndian, b.m)
+	binary.Write(&header, binary.BigEndian, b.hashes)
+	if _, err := zw.Write(header.Bytes()); err != nil {
+		return counter.n, err
+	}
+	if err := binary.Write(zw, binary.BigEndian, b.bits); err != nil {
+		return counter.n, err
+	}
+	err := zw.Close()
+	return counter.n, err
+}
+
+// ReadBloomFilter reads a filter written by WriteTo.
+func ReadBloomFilter(r io.Reader) (*BloomFilter, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bloom filter: %w", err)
+	}
+	defer zr.Close()
+	magic := make([]byte, len(bloomMagic))
+	if _, err := io.ReadFull(zr, magic); err != nil || string(magic) != bloomMagic {
+		return nil, fmt.Errorf("invalid bloom filter")
+	}
+	var b BloomFilter
+	if err := binary.Read(zr, binary.BigEndian, &b.m); err != nil {
+		return nil, fmt.Errorf("invalid bloom filter: %w", err)
+	}
+	if err := binary.Read(zr, binary.BigEndian, &b.hashes); err != nil {
+		return nil, fmt.Errorf("invalid bloom filter: %w", err)
+	}
+	if b.m == 0 || b.hashes == 0 {
+		return nil, fmt.Errorf("invalid bloom filter")
+	}
+	b.bits = make([]uint64, (b.m+63)/64)
+	if err := binary.Read(zr, binary.BigEndian, b.bits); err != nil {
+		return nil, fmt.Errorf("invalid bloom filter: %w", err)
+	}
+	return &b, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
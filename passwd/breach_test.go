@@ -0,0 +1,173 @@
+package passwd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var breachedPasswords = []string{"password", "123456", "qwerty", "iloveyou", "P@ssw0rd", "dragon", "monkey"}
+
+// writeCorpus writes the lines to a file of the test's temporary directory.
+func writeCorpus(t *testing.T, lines []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "corpus.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSortedHashFile(t *testing.T) {
+	var lines []string
+	for i, password := range breachedPasswords {
+		line := sha1Hex(password)
+		// Lines may carry counts and CRLF endings, as HIBP's do.
+		if i%2 == 0 {
+			line = fmt.Sprintf("%s:%d", line, i+1)
+		}
+		lines = append(lines, line+"\r\n")
+	}
+	slices.Sort(lines)
+
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"hashes", lines},
+		{"no final line ending", append(lines[:len(lines)-1:len(lines)-1], strings.TrimSpace(lines[len(lines)-1]))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corpus, err := OpenCorpus(writeCorpus(t, tt.lines))
+			if err != nil {
+				t.Fatalf("OpenCorpus() error = %v", err)
+			}
+			defer corpus.Close()
+			if _, ok := corpus.(*SortedHashFile); !ok {
+				t.Fatalf("OpenCorpus() = %T, want *SortedHashFile", corpus)
+			}
+			testCorpus(t, corpus, false)
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		corpus, err := OpenCorpus(writeCorpus(t, nil))
+		if err != nil {
+			t.Fatalf("OpenCorpus() error = %v", err)
+		}
+		defer corpus.Close()
+		if found, err := corpus.Contains("password"); err != nil || found {
+			t.Errorf("Contains() = %v, %v, want false", found, err)
+		}
+	})
+}
+
+func TestSortedHashFileClose(t *testing.T) {
+	corpus, err := OpenCorpus(writeCorpus(t, []string{sha1Hex("password") + "\n"}))
+	if err != nil {
+		t.Fatalf("OpenCorpus() error = %v", err)
+	}
+	if err := corpus.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := corpus.Contains("password"); err == nil {
+		t.Error("Contains() after Close() error = nil")
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	filter := NewBloomFilter(len(breachedPasswords), 0.001)
+	for _, password := range breachedPasswords[1:] {
+		filter.Add(password)
+	}
+	if err := filter.AddHash(strings.ToLower(sha1Hex(breachedPasswords[0])) + ":42"); err != nil {
+		t.Fatalf("AddHash() error = %v", err)
+	}
+	for _, hash := range []string{"", "abc", strings.Repeat("z", 40)} {
+		if err := filter.AddHash(hash); err == nil {
+			t.Errorf("AddHash(%q) error = nil", hash)
+		}
+	}
+	testCorpus(t, filter, true)
+
+	var buf bytes.Buffer
+	if _, err := filter.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "corpus.bloom")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	corpus, err := OpenCorpus(path)
+	if err != nil {
+		t.Fatalf("OpenCorpus() error = %v", err)
+	}
+	defer corpus.Close()
+	if _, ok := corpus.(*BloomFilter); !ok {
+		t.Fatalf("OpenCorpus() = %T, want *BloomFilter", corpus)
+	}
+	testCorpus(t, corpus, true)
+}
+
+func TestBloomFilterFalsePositives(t *testing.T) {
+	filter := NewBloomFilter(1000, 0.01)
+	for i := range 1000 {
+		filter.Add(fmt.Sprintf("breached-%d", i))
+	}
+	var positives int
+	for i := range 10000 {
+		if found, _ := filter.Contains(fmt.Sprintf("other-%d", i)); found {
+			positives++
+		}
+	}
+	// Allow for some variance around the 1% the filter is sized for.
+	if positives > 300 {
+		t.Errorf("Contains() reported %d of 10000 absent passwords, want about 100", positives)
+	}
+}
+
+func TestReadBloomFilterInvalid(t *testing.T) {
+	var valid bytes.Buffer
+	NewBloomFilter(10, 0.01).WriteTo(&valid)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not gzipped", []byte("PWBLOOM1")},
+		{"truncated", valid.Bytes()[:valid.Len()/2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadBloomFilter(bytes.NewReader(tt.data)); err == nil {
+				t.Error("ReadBloomFilter() error = nil")
+			}
+		})
+	}
+}
+
+// testCorpus checks that the corpus holds the breached passwords and, unless
+// it may report false positives, nothing else.
+func testCorpus(t *testing.T, corpus Corpus, approximate bool) {
+	t.Helper()
+	for _, password := range breachedPasswords {
+		if found, err := corpus.Contains(password); err != nil || !found {
+			t.Errorf("Contains(%q) = %v, %v, want true", password, found, err)
+		}
+	}
+	if approximate {
+		return
+	}
+	// Their hashes sort before, between and after the breached ones.
+	for _, password := range []string{"ninja", "Password", "hunter2", "correct horse battery staple", "x7#Kq!9vLm2$Wz", ""} {
+		if found, err := corpus.Contains(password); err != nil || found {
+			t.Errorf("Contains(%q) = %v, %v, want false", password, found, err)
+		}
+	}
+}
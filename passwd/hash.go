@@ -0,0 +1,257 @@
+package passwd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrUnknownHash is returned when no hasher recognizes a stored hash.
+	ErrUnknownHash = errors.New("unknown password hash format")
+	// ErrHashParameters is wrapped by the errors of stored hashes whose cost
+	// parameters are out of bounds, so that verifying them would panic,
+	// stall or exhaust memory.
+	ErrHashParameters = errors.New("password hash parameters out of bounds")
+)
+
+// maxBcryptCost bounds the cost of stored bcrypt hashes: each step doubles
+// the time a login takes.
+const maxBcryptCost = 16
+
+// PasswordHasher hashes passwords into self-describing strings that carry
+// the algorithm and its parameters.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Identify reports whether the hash was produced by this algorithm.
+	Identify(hash string) bool
+	Verify(password, hash string) (bool, error)
+	// Check parses the hash without verifying a password, returning
+	// ErrHashParameters when verifying it would be too costly.
+	Check(hash string) error
+	// NeedsRehash reports whether the hash was produced with parameters
+	// other than the hasher's.
+	NeedsRehash(hash string) bool
+}
+
+// DefaultHasher is used when the application configures none. It produces
+// the same hashes the module always stored.
+var DefaultHasher PasswordHasher = Bcrypt{Cost: bcrypt.DefaultCost}
+
+// Verify checks the password against a hash produced by any of the hashers.
+// The first hasher is the current one: rehash is set when the hash was
+// produced by another hasher or with outdated parameters.
+func Verify(password, hash string, hashers ...PasswordHasher) (ok, rehash bool, err error) {
+	for i, h := range hashers {
+		if !h.Identify(hash) {
+			continue
+		}
+		ok, err := h.Verify(password, hash)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, i > 0 || h.NeedsRehash(hash), nil
+	}
+	return false, false, ErrUnknownHash
+}
+
+// Check parses a hash produced by any of the hashers, as Verify would.
+func Check(hash string, hashers ...PasswordHasher) error {
+	h, ok := Identify(hash, hashers...)
+	if !ok {
+		return ErrUnknownHash
+	}
+	return h.Check(hash)
+}
+
+// Bcrypt hashes with bcrypt at the given cost.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) cost() int {
+	if b.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return b.Cost
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost())
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func (b Bcrypt) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$")
+}
+
+func (b Bcrypt) Verify(password, hash string) (bool, error) {
+	if err := b.Check(hash); err != nil {
+		return false, err
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.cost()
+}
+
+func (b Bcrypt) Check(hash string) error {
+	if len(hash) != 60 {
+		return fmt.Errorf("invalid bcrypt hash")
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return fmt.Errorf("invalid bcrypt hash: %w", err)
+	}
+	if cost > max(maxBcryptCost, b.cost()) {
+		return fmt.Errorf("%w: bcrypt cost %d", ErrHashParameters, cost)
+	}
+	return nil
+}
+
+// Argon2id hashes with Argon2id into the PHC string format:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+//
+// Zero fields take the values of DefaultArgon2id.
+type Argon2id struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+	// MaxMemory is the most memory, in KiB, a stored hash may ask for.
+	// Hashes asking for more are rejected instead of verified.
+	MaxMemory uint32
+}
+
+// DefaultArgon2id follows the OWASP recommendation of 19 MiB, two passes
+// and one lane, and accepts stored hashes of up to 256 MiB.
+var DefaultArgon2id = Argon2id{Time: 2, Memory: 19 * 1024, Threads: 1, SaltLen: 16, KeyLen: 32, MaxMemory: 256 * 1024}
+
+// Bounds of the other parameters accepted from stored Argon2id hashes.
+const (
+	maxArgon2Time   = 16
+	minArgon2Salt   = 8
+	maxArgon2KeyLen = 128
+)
+
+func (a Argon2id) params() Argon2id {
+	d := DefaultArgon2id
+	if a.Time != 0 {
+		d.Time = a.Time
+	}
+	if a.Memory != 0 {
+		d.Memory = a.Memory
+	}
+	if a.Threads != 0 {
+		d.Threads = a.Threads
+	}
+	if a.SaltLen != 0 {
+		d.SaltLen = a.SaltLen
+	}
+	if a.KeyLen != 0 {
+		d.KeyLen = a.KeyLen
+	}
+	if a.MaxMemory != 0 {
+		d.MaxMemory = a.MaxMemory
+	}
+	d.MaxMemory = max(d.MaxMemory, d.Memory)
+	return d
+}
+
+func (a Argon2id) Hash(password string) (string, error) {
+	p := a.params()
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a Argon2id) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (a Argon2id) Verify(password, hash string) (bool, error) {
+	p, salt, key, err := a.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a Argon2id) Check(hash string) error {
+	_, _, _, err := a.decode(hash)
+	return err
+}
+
+func (a Argon2id) NeedsRehash(hash string) bool {
+	p, salt, key, err := a.decode(hash)
+	if err != nil {
+		return true
+	}
+	want := a.params()
+	return p.Time != want.Time || p.Memory != want.Memory || p.Threads != want.Threads ||
+		uint32(len(salt)) != want.SaltLen || uint32(len(key)) != want.KeyLen
+}
+
+// decode parses a PHC string, rejecting parameters that argon2.IDKey would
+// panic on or that exceed the bounds of the hasher.
+func (a Argon2id) decode(hash string) (p Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id parameters %q", parts[3])
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id salt")
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id key")
+	}
+
+	switch maxMemory := a.params().MaxMemory; {
+	case p.Time < 1 || p.Time > maxArgon2Time:
+		err = fmt.Errorf("%w: argon2id time %d", ErrHashParameters, p.Time)
+	case p.Threads < 1:
+		err = fmt.Errorf("%w: argon2id parallelism %d", ErrHashParameters, p.Threads)
+	case p.Memory < 8*uint32(p.Threads) || p.Memory > maxMemory:
+		err = fmt.Errorf("%w: argon2id memory %d KiB", ErrHashParameters, p.Memory)
+	case len(salt) < minArgon2Salt:
+		err = fmt.Errorf("%w: argon2id salt of %d bytes", ErrHashParameters, len(salt))
+	case len(key) == 0 || len(key) > maxArgon2KeyLen:
+		err = fmt.Errorf("%w: argon2id key of %d bytes", ErrHashParameters, len(key))
+	}
+	if err != nil {
+		return p, nil, nil, err
+	}
+	return p, salt, key, nil
+}
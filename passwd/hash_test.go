@@ -0,0 +1,155 @@
+package passwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// A known Argon2id key of "password" salted with "somesalt", from the
+// vectors of the reference implementation.
+const (
+	argonSalt = "c29tZXNhbHQ"
+	argonKey  = "NQrDciL0Nsy1wJcvHr079rlYvyBxhBNi"
+)
+
+func TestHashRoundTrip(t *testing.T) {
+	// Low costs keep the tests quick.
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+	}{
+		{"bcrypt", Bcrypt{Cost: 4}},
+		{"argon2id", Argon2id{Time: 1, Memory: 64, Threads: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testRoundTrip(t, tt.hasher)
+		})
+	}
+}
+
+func testRoundTrip(t *testing.T, h PasswordHasher) {
+	t.Helper()
+	hash, err := h.Hash("Hello world!")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !h.Identify(hash) {
+		t.Fatalf("Identify(%q) = false", hash)
+	}
+	if h.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash(%q) = true", hash)
+	}
+	if ok, err := h.Verify("Hello world!", hash); err != nil || !ok {
+		t.Errorf("Verify() = %v, %v, want true", ok, err)
+	}
+	if ok, err := h.Verify("hello world!", hash); err != nil || ok {
+		t.Errorf("Verify() of another password = %v, %v, want false", ok, err)
+	}
+}
+
+func TestVerifyKnownVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+	}{
+		{"bcrypt", "U*U", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW"},
+		{"argon2id", "password", "$argon2id$v=19$m=64,t=2,p=2$" + argonSalt + "$" + argonKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testKnownVector(t, tt.password, tt.hash, DefaultHasher, DefaultArgon2id)
+		})
+	}
+}
+
+func testKnownVector(t *testing.T, password, hash string, hashers ...PasswordHasher) {
+	t.Helper()
+	if ok, _, err := Verify(password, hash, hashers...); err != nil || !ok {
+		t.Errorf("Verify() = %v, %v, want true", ok, err)
+	}
+	if ok, _, err := Verify(password+"x", hash, hashers...); err != nil || ok {
+		t.Errorf("Verify() of another password = %v, %v, want false", ok, err)
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	current := Argon2id{Time: 1, Memory: 64, Threads: 1}
+	other, _ := Bcrypt{Cost: 4}.Hash("Hello world!")
+	outdated, _ := Argon2id{Time: 2, Memory: 64, Threads: 1}.Hash("Hello world!")
+	fresh, _ := current.Hash("Hello world!")
+
+	tests := []struct {
+		name   string
+		hash   string
+		rehash bool
+	}{
+		{"other hasher", other, true},
+		{"outdated parameters", outdated, true},
+		{"current", fresh, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := Verify("Hello world!", tt.hash, current, Bcrypt{Cost: 4})
+			if err != nil || !ok {
+				t.Fatalf("Verify() = %v, %v, want true", ok, err)
+			}
+			if rehash != tt.rehash {
+				t.Errorf("Verify() rehash = %v, want %v", rehash, tt.rehash)
+			}
+		})
+	}
+	if _, _, err := Verify("Hello world!", "plain", current); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("Verify() of an unknown hash error = %v, want ErrUnknownHash", err)
+	}
+}
+
+func TestVerifyHostileHashes(t *testing.T) {
+	argon := func(params string) string {
+		return "$argon2id$v=19$" + params + "$" + argonSalt + "$" + argonKey
+	}
+	tests := []struct {
+		name   string
+		hash   string
+		bounds bool // the error is ErrHashParameters
+	}{
+		{"bcrypt cost above the bound", "$2a$31$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", true},
+		{"bcrypt cost not a number", "$2a$xx$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", false},
+		{"argon2id missing parts", "$argon2id$v=19$m=64,t=1,p=1$" + argonSalt, false},
+		{"argon2id other version", "$argon2id$v=16$m=64,t=1,p=1$" + argonSalt + "$" + argonKey, false},
+		{"argon2id unparsable parameters", argon("m=x,t=1,p=1"), false},
+		{"argon2id bad salt", "$argon2id$v=19$m=64,t=1,p=1$!!$" + argonKey, false},
+		{"argon2id zero time", argon("m=64,t=0,p=1"), true},
+		{"argon2id time above the bound", argon("m=64,t=1000,p=1"), true},
+		{"argon2id zero parallelism", argon("m=64,t=1,p=0"), true},
+		{"argon2id memory below 8 per thread", argon("m=8,t=1,p=4"), true},
+		{"argon2id memory above the bound", argon("m=4194304,t=1,p=1"), true},
+		{"argon2id short salt", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + argonKey, true},
+		{"argon2id empty key", "$argon2id$v=19$m=64,t=1,p=1$" + argonSalt + "$", true},
+		{"argon2id oversized key", "$argon2id$v=19$m=64,t=1,p=1$" + argonSalt + "$" + strings.Repeat("A", 200), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, _, err := Verify("password", tt.hash, DefaultHasher, DefaultArgon2id)
+			if err == nil || ok {
+				t.Fatalf("Verify(%q) = %v, %v, want an error", tt.hash, ok, err)
+			}
+			if errors.Is(err, ErrHashParameters) != tt.bounds {
+				t.Errorf("Verify(%q) error = %v, want ErrHashParameters %v", tt.hash, err, tt.bounds)
+			}
+		})
+	}
+}
+
+func TestArgon2idMaxMemory(t *testing.T) {
+	hash := "$argon2id$v=19$m=4096,t=2,p=1$" + argonSalt + "$" + argonKey
+	if _, err := (Argon2id{Memory: 1024, MaxMemory: 2048}).Verify("password", hash); !errors.Is(err, ErrHashParameters) {
+		t.Errorf("Verify() with a lower cap error = %v, want ErrHashParameters", err)
+	}
+	// The cap never rejects the hasher's own memory.
+	if _, err := (Argon2id{Memory: 4096, MaxMemory: 1024}).Verify("password", hash); err != nil {
+		t.Errorf("Verify() with the hasher's memory error = %v", err)
+	}
+}
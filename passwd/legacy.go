@@ -0,0 +1,335 @@
+package passwd
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The hashers below verify the hashes of systems users are imported from.
+// They can hash too, but are meant to be listed after the current hasher so
+// that their hashes get replaced at the next login.
+
+// Legacy returns the hashers of the supported foreign formats.
+func Legacy() []PasswordHasher {
+	return []PasswordHasher{PBKDF2SHA256{}, SHA512Crypt{}, LegacyBcrypt{}}
+}
+
+// Identify returns the hasher that produced the hash, if any.
+func Identify(hash string, hashers ...PasswordHasher) (PasswordHasher, bool) {
+	for _, h := range hashers {
+		if h.Identify(hash) {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+// Bounds of the cost parameters accepted from stored hashes, well above what
+// the systems they come from produce.
+const (
+	maxPBKDF2Iterations  = 2000000
+	maxPBKDF2KeyLen      = 64
+	maxSHA512CryptRounds = 1000000
+)
+
+// PBKDF2SHA256 handles the default hashes of Django:
+//
+//	pbkdf2_sha256$<iterations>$<salt>$<base64 key>
+type PBKDF2SHA256 struct {
+	Iterations int
+}
+
+func (p PBKDF2SHA256) iterations() int {
+	if p.Iterations == 0 {
+		return 600000
+	}
+	return p.Iterations
+}
+
+func (p PBKDF2SHA256) Hash(password string) (string, error) {
+	salt := make([]byte, 12)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	s := base64.RawURLEncoding.EncodeToString(salt)
+	key, err := pbkdf2.Key(sha256.New, password, []byte(s), p.iterations(), sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return fmt.Sprintf("pbkdf2_sha256$%d$%s$%s", p.iterations(), s, base64.StdEncoding.EncodeToString(key)), nil
+}
+
+func (p PBKDF2SHA256) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "pbkdf2_sha256$")
+}
+
+func (p PBKDF2SHA256) Verify(password, hash string) (bool, error) {
+	iterations, salt, want, err := p.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, []byte(salt), iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1, nil
+}
+
+func (p PBKDF2SHA256) Check(hash string) error {
+	_, _, _, err := p.decode(hash)
+	return err
+}
+
+func (p PBKDF2SHA256) NeedsRehash(hash string) bool {
+	parts := strings.Split(hash, "$")
+	return len(parts) != 4 || parts[1] != strconv.Itoa(p.iterations())
+}
+
+// decode parses a Django hash. Every 32 bytes of key cost another run of
+// the iterations, so its length is bounded too.
+func (p PBKDF2SHA256) decode(hash string) (iterations int, salt string, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2_sha256" {
+		return 0, "", nil, ErrUnknownHash
+	}
+	iterations, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("invalid pbkdf2_sha256 iterations %q", parts[1])
+	}
+	if iterations < 1 || iterations > max(maxPBKDF2Iterations, p.iterations()) {
+		return 0, "", nil, fmt.Errorf("%w: pbkdf2_sha256 iterations %d", ErrHashParameters, iterations)
+	}
+	key, err = base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return 0, "", nil, fmt.Errorf("invalid pbkdf2_sha256 key")
+	}
+	if len(key) > maxPBKDF2KeyLen {
+		return 0, "", nil, fmt.Errorf("%w: pbkdf2_sha256 key of %d bytes", ErrHashParameters, len(key))
+	}
+	return iterations, parts[2], key, nil
+}
+
+// LegacyBcrypt handles the $2y$ bcrypt hashes of PHP, which are the same as
+// $2b$ hashes under another name.
+type LegacyBcrypt struct {
+	Cost int
+}
+
+func (b LegacyBcrypt) Hash(password string) (string, error) {
+	hash, err := Bcrypt(b).Hash(password)
+	if err != nil {
+		return "", err
+	}
+	return "$2y$" + hash[4:], nil
+}
+
+func (b LegacyBcrypt) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$2y$")
+}
+
+func (b LegacyBcrypt) Verify(password, hash string) (bool, error) {
+	return Bcrypt(b).Verify(password, hash)
+}
+
+func (b LegacyBcrypt) Check(hash string) error {
+	return Bcrypt(b).Check(hash)
+}
+
+func (b LegacyBcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != Bcrypt(b).cost()
+}
+
+// SHA512Crypt handles the $6$ hashes of crypt(3), as produced by glibc and
+// PHP's crypt with CRYPT_SHA512:
+//
+//	$6$[rounds=<n>$]<salt>$<hash>
+type SHA512Crypt struct {
+	Rounds int
+}
+
+const (
+	sha512CryptDefaultRounds = 5000
+	sha512CryptAlphabet      = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+func (c SHA512Crypt) rounds() int {
+	if c.Rounds == 0 {
+		return sha512CryptDefaultRounds
+	}
+	return min(max(c.Rounds, 1000), 999999999)
+}
+
+func (c SHA512Crypt) Hash(password string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	salt := make([]byte, 16)
+	for i, b := range raw {
+		salt[i] = sha512CryptAlphabet[int(b)%len(sha512CryptAlphabet)]
+	}
+	return sha512Crypt([]byte(password), salt, c.rounds(), c.rounds() != sha512CryptDefaultRounds), nil
+}
+
+func (c SHA512Crypt) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$6$")
+}
+
+func (c SHA512Crypt) Verify(password, hash string) (bool, error) {
+	salt, rounds, custom, err := c.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	other := sha512Crypt([]byte(password), salt, rounds, custom)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(other)) == 1, nil
+}
+
+func (c SHA512Crypt) Check(hash string) error {
+	_, _, _, err := c.decode(hash)
+	return err
+}
+
+// decode parses the hash, rejecting rounds above the bound of the hasher
+// and checksums that crypt(3) couldn't have produced.
+func (c SHA512Crypt) decode(hash string) (salt []byte, rounds int, custom bool, err error) {
+	salt, rounds, custom, err = parseSHA512Crypt(hash)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if rounds > max(maxSHA512CryptRounds, c.rounds()) {
+		return nil, 0, false, fmt.Errorf("%w: sha512-crypt rounds %d", ErrHashParameters, rounds)
+	}
+	sum := hash[strings.LastIndexByte(hash, '$')+1:]
+	if len(sum) != 86 || strings.Trim(sum, sha512CryptAlphabet) != "" {
+		return nil, 0, false, fmt.Errorf("invalid sha512-crypt hash")
+	}
+	return salt, rounds, custom, nil
+}
+
+func (c SHA512Crypt) NeedsRehash(hash string) bool {
+	_, rounds, _, err := parseSHA512Crypt(hash)
+	return err != nil || rounds != c.rounds()
+}
+
+func parseSHA512Crypt(hash string) (salt []byte, rounds int, custom bool, err error) {
+	rest, ok := strings.CutPrefix(hash, "$6$")
+	if !ok {
+		return nil, 0, false, ErrUnknownHash
+	}
+	rounds = sha512CryptDefaultRounds
+	if r, after, ok := strings.Cut(rest, "$"); ok && strings.HasPrefix(r, "rounds=") {
+		n, err := strconv.Atoi(strings.TrimPrefix(r, "rounds="))
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("invalid sha512-crypt rounds %q", r)
+		}
+		rounds, custom, rest = min(max(n, 1000), 999999999), true, after
+	}
+	s, _, ok := strings.Cut(rest, "$")
+	if !ok {
+		return nil, 0, false, fmt.Errorf("invalid sha512-crypt hash")
+	}
+	if len(s) > 16 {
+		s = s[:16]
+	}
+	return []byte(s), rounds, custom, nil
+}
+
+// sha512Crypt implements the SHA-512 based crypt of Ulrich Drepper's
+// specification.
+func sha512Crypt(password, salt []byte, rounds int, custom bool) string {
+	b := sha512.New()
+	b.Write(password)
+	b.Write(salt)
+	b.Write(password)
+	sumB := b.Sum(nil)
+
+	a := sha512.New()
+	a.Write(password)
+	a.Write(salt)
+	for i := len(password); i > 0; i -= sha512.Size {
+		a.Write(sumB[:min(i, sha512.Size)])
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			a.Write(sumB)
+		} else {
+			a.Write(password)
+		}
+	}
+	sumA := a.Sum(nil)
+
+	dp := sha512.New()
+	for range password {
+		dp.Write(password)
+	}
+	p := repeatTo(dp.Sum(nil), len(password))
+
+	ds := sha512.New()
+	for i := 0; i < 16+int(sumA[0]); i++ {
+		ds.Write(salt)
+	}
+	s := repeatTo(ds.Sum(nil), len(salt))
+
+	c := sumA
+	for i := 0; i < rounds; i++ {
+		h := sha512.New()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(p)
+		}
+		c = h.Sum(nil)
+	}
+
+	var out strings.Builder
+	out.WriteString("$6$")
+	if custom {
+		fmt.Fprintf(&out, "rounds=%d$", rounds)
+	}
+	out.Write(salt)
+	out.WriteByte('$')
+	encode := func(b2, b1, b0 byte, n int) {
+		w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+		for ; n > 0; n-- {
+			out.WriteByte(sha512CryptAlphabet[w&0x3f])
+			w >>= 6
+		}
+	}
+	// Each group of three bytes (i, i+21, i+42) is rotated by i%3.
+	for i := 0; i < 21; i++ {
+		g := [3]byte{c[i], c[i+21], c[i+42]}
+		r := i % 3
+		encode(g[r], g[(r+1)%3], g[(r+2)%3], 4)
+	}
+	encode(0, 0, c[63], 2)
+	return out.String()
+}
+
+func repeatTo(sum []byte, n int) []byte {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		out = append(out, sum[:min(len(sum), n-len(out))]...)
+	}
+	return out
+}
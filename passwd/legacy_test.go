@@ -0,0 +1,93 @@
+package passwd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLegacyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+	}{
+		{"pbkdf2_sha256", PBKDF2SHA256{Iterations: 1000}},
+		{"php bcrypt", LegacyBcrypt{Cost: 4}},
+		{"sha512-crypt", SHA512Crypt{Rounds: 1000}},
+		{"sha512-crypt default rounds", SHA512Crypt{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testRoundTrip(t, tt.hasher)
+		})
+	}
+}
+
+func TestLegacyKnownVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+	}{
+		{"php bcrypt", "U*U", "$2y$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW"},
+		{"pbkdf2_sha256", "Hello world!", "pbkdf2_sha256$1000$saltstring$CKu1ohp/h1Vmf80EejAnSuclLDEwhEBXG0hR/FfkNJ4="},
+		{"sha512-crypt", "Hello world!", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{"sha512-crypt rounds", "Hello world!", "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."},
+		{"sha512-crypt default rounds given", "This is just a test", "$6$rounds=5000$toolongsaltstrin$lQ8jolhgVRVhY4b5pZKaysCLi0QBxGoNeKQzQ3glMhwllF7oGDZxUhx1yxdYcz/e1JSbq3y6JMxxl8audkUEm0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testKnownVector(t, tt.password, tt.hash, Legacy()...)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	sum := strings.Repeat("a", 86)
+	tests := []struct {
+		name string
+		hash string
+		err  error // nil for any error but ErrHashParameters
+	}{
+		{"unknown format", "md5$abc", ErrUnknownHash},
+		{"bcrypt truncated", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvy", nil},
+		{"php bcrypt cost above the bound", "$2y$31$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", ErrHashParameters},
+		{"argon2id zero time", "$argon2id$v=19$m=64,t=0,p=1$" + argonSalt + "$" + argonKey, ErrHashParameters},
+		{"pbkdf2 iterations not a number", "pbkdf2_sha256$x$salt$CKu1ohp/h1Vmf80EejAnSuclLDEwhEBXG0hR/FfkNJ4=", nil},
+		{"pbkdf2 zero iterations", "pbkdf2_sha256$0$salt$CKu1ohp/h1Vmf80EejAnSuclLDEwhEBXG0hR/FfkNJ4=", ErrHashParameters},
+		{"pbkdf2 iterations above the bound", "pbkdf2_sha256$999999999$salt$CKu1ohp/h1Vmf80EejAnSuclLDEwhEBXG0hR/FfkNJ4=", ErrHashParameters},
+		{"pbkdf2 empty key", "pbkdf2_sha256$1000$salt$", nil},
+		{"pbkdf2 oversized key", "pbkdf2_sha256$1000$salt$" + strings.Repeat("AAAA", 32), ErrHashParameters},
+		{"sha512-crypt rounds above the bound", "$6$rounds=999999999$salt$" + sum, ErrHashParameters},
+		{"sha512-crypt rounds not a number", "$6$rounds=x$salt$" + sum, nil},
+		{"sha512-crypt missing checksum", "$6$salt", nil},
+		{"sha512-crypt short checksum", "$6$salt$abc", nil},
+		{"sha512-crypt checksum outside the alphabet", "$6$salt$" + strings.Repeat("!", 86), nil},
+	}
+	hashers := append([]PasswordHasher{DefaultHasher, DefaultArgon2id}, Legacy()...)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.hash, hashers...)
+			if err == nil {
+				t.Fatalf("Check(%q) error = nil", tt.hash)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Check(%q) error = %v, want %v", tt.hash, err, tt.err)
+			}
+			if tt.err == nil && errors.Is(err, ErrHashParameters) {
+				t.Errorf("Check(%q) error = %v, want a parse error", tt.hash, err)
+			}
+		})
+	}
+
+	valid := []string{
+		"$2y$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		"pbkdf2_sha256$1000$saltstring$CKu1ohp/h1Vmf80EejAnSuclLDEwhEBXG0hR/FfkNJ4=",
+		"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+	}
+	for _, hash := range valid {
+		if err := Check(hash, hashers...); err != nil {
+			t.Errorf("Check(%q) error = %v", hash, err)
+		}
+	}
+}
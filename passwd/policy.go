@@ -0,0 +1,175 @@
+// Package passwd holds the rules passwords must follow.
+package passwd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrPolicy is wrapped by every PolicyError.
+var ErrPolicy = errors.New("password doesn't meet the policy")
+
+// Policy lists the rules a new password must follow. The zero value
+// enforces nothing; see DefaultPolicy.
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// History is how many previous passwords can't be reused.
+	History int
+	// MaxAge is how long a password stays valid before it must be changed
+	// at login. Zero never expires passwords.
+	MaxAge time.Duration
+	// ForbidPersonalInfo rejects passwords containing the names, username
+	// or email of the user.
+	ForbidPersonalInfo bool
+	// MinScore is the lowest strength, from 0 to 4, a password must reach
+	// according to EstimateStrength. Zero accepts any.
+	MinScore int
+}
+
+// DefaultPolicy matches the rules passwords always had: at least 8
+// characters with an uppercase letter, a digit and a symbol, which must
+// not be an easily guessed password such as Password1!.
+var DefaultPolicy = Policy{
+	MinLength:     8,
+	RequireUpper:  true,
+	RequireDigit:  true,
+	RequireSymbol: true,
+	MinScore:      2,
+}
+
+// UserInfo is what is known about the owner of a password.
+type UserInfo struct {
+	FirstName string
+	LastName  string
+	Username  string
+	Email     string
+}
+
+// PolicyError lists every rule a password breaks. Strength is set when the
+// password is too weak for the policy's MinScore.
+type PolicyError struct {
+	Problems []string
+	Strength *Strength
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPolicy, strings.Join(e.Problems, "; "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicy
+}
+
+// Validate checks the password against the rules that only need the
+// password and its owner, and against the breached passwords when given.
+// History and age are checked by the caller, who holds the stored hashes.
+func (p Policy) Validate(password string, user UserInfo, breached Corpus) error {
+	var problems []string
+	if n := len([]rune(password)); n < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must have at least %d characters", p.MinLength))
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsSymbol(r), unicode.IsPunct(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "must contain a number")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "must contain a symbol")
+	}
+	if p.ForbidPersonalInfo {
+		if word := personalInfoIn(password, user); word != "" {
+			problems = append(problems, fmt.Sprintf("must not contain %q", word))
+		}
+	}
+	var strength *Strength
+	if p.MinScore > 0 {
+		// Only a prefix is scored, whoever calls with a long password.
+		local, _, _ := strings.Cut(user.Email, "@")
+		s := EstimateStrength(strengthPrefix(password), user.FirstName, user.LastName, user.Username, local)
+		if s.Score < p.MinScore {
+			problems = append(problems, fmt.Sprintf("is too weak, scoring %d of 4 where %d is required: %s",
+				s.Score, p.MinScore, strings.Join(s.Feedback(), ". ")))
+			strength = &s
+		}
+	}
+	if breached != nil {
+		found, err := breached.Contains(password)
+		if err != nil {
+			return fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+		if found {
+			problems = append(problems, "appears in a data breach and must not be used")
+		}
+	}
+	if len(problems) > 0 {
+		return &PolicyError{Problems: problems, Strength: strength}
+	}
+	return nil
+}
+
+// Expired reports whether a password changed at changedAt must be changed
+// at now.
+func (p Policy) Expired(changedAt, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(changedAt) >= p.MaxAge
+}
+
+// Strictest combines the policies into one enforcing all of them.
+func Strictest(policies ...Policy) Policy {
+	var s Policy
+	for _, p := range policies {
+		s.MinLength = max(s.MinLength, p.MinLength)
+		s.RequireUpper = s.RequireUpper || p.RequireUpper
+		s.RequireLower = s.RequireLower || p.RequireLower
+		s.RequireDigit = s.RequireDigit || p.RequireDigit
+		s.RequireSymbol = s.RequireSymbol || p.RequireSymbol
+		s.History = max(s.History, p.History)
+		if p.MaxAge > 0 && (s.MaxAge == 0 || p.MaxAge < s.MaxAge) {
+			s.MaxAge = p.MaxAge
+		}
+		s.ForbidPersonalInfo = s.ForbidPersonalInfo || p.ForbidPersonalInfo
+		s.MinScore = max(s.MinScore, p.MinScore)
+	}
+	return s
+}
+
+// personalInfoIn returns the first word of the user's details found in the
+// password, ignoring case. Words shorter than 3 characters are skipped.
+func personalInfoIn(password string, user UserInfo) string {
+	lower := strings.ToLower(password)
+	local, _, _ := strings.Cut(user.Email, "@")
+	for _, field := range []string{user.FirstName, user.LastName, user.Username, local} {
+		words := strings.FieldsFunc(strings.ToLower(field), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			if len([]rune(word)) >= 3 && strings.Contains(lower, word) {
+				return word
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,558 @@
+package passwd
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// common.txt lists common passwords and words, most common first.
+//
+//go:embed common.txt
+var commonText string
+
+var commonRanks = rankWords(strings.Fields(commonText))
+
+// Strength is an estimate of how many guesses an attacker who knows common
+// passwords, keyboard patterns and the user's details needs to find a
+// password, in the manner of zxcvbn.
+type Strength struct {
+	// Score goes from 0, guessed in under a thousand tries, to 4, which
+	// needs more than 10^10.
+	Score       int
+	Guesses     float64
+	Warning     string
+	Suggestions []string
+}
+
+// Feedback returns the warning followed by the suggestions.
+func (s Strength) Feedback() []string {
+	var feedback []string
+	if s.Warning != "" {
+		feedback = append(feedback, s.Warning)
+	}
+	return append(feedback, s.Suggestions...)
+}
+
+type pattern int
+
+const (
+	patternBruteforce pattern = iota
+	patternDictionary
+	patternUserInput
+	patternSequence
+	patternRepeat
+	patternSpatial
+	patternYear
+)
+
+// match is a guessable part of a password, from rune i to j inclusive.
+type match struct {
+	i, j     int
+	pattern  pattern
+	guesses  float64
+	token    string
+	rank     int
+	l33t     bool
+	reversed bool
+	turns    int
+	unit     string
+}
+
+// minGuessesBeforeGrowingSequence is added for every match past the first,
+// as an attacker tries short sequences of patterns before long ones.
+const minGuessesBeforeGrowingSequence = 10000
+
+// maxStrengthRunes bounds the part of a password EstimateStrength scores:
+// its cost grows faster than the length, and a password this long is
+// strong enough when its prefix is.
+const maxStrengthRunes = 72
+
+// EstimateStrength estimates the strength of the password, of which only
+// the first 72 characters are scored. userInputs are words an attacker may
+// know about the user, such as their names.
+func EstimateStrength(password string, userInputs ...string) Strength {
+	runes := []rune(strengthPrefix(password))
+	if len(runes) == 0 {
+		return Strength{Warning: "Password is empty", Suggestions: []string{defaultSuggestion}}
+	}
+	var inputs []string
+	for _, input := range userInputs {
+		inputs = append(inputs, strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+		inputs = append(inputs, strings.ToLower(input))
+	}
+	guesses, sequence := mostGuessable(runes, rankWords(inputs))
+	s := Strength{Guesses: guesses, Score: score(guesses)}
+	if s.Score < 3 {
+		s.Warning, s.Suggestions = feedback(sequence)
+	}
+	return s
+}
+
+// strengthPrefix returns the part of the password EstimateStrength scores.
+func strengthPrefix(password string) string {
+	runes := []rune(password)
+	return string(runes[:min(len(runes), maxStrengthRunes)])
+}
+
+func score(guesses float64) int {
+	switch {
+	case guesses < 1e3+5:
+		return 0
+	case guesses < 1e6+5:
+		return 1
+	case guesses < 1e8+5:
+		return 2
+	case guesses < 1e10+5:
+		return 3
+	}
+	return 4
+}
+
+// mostGuessable finds the sequence of matches covering the password that
+// needs the fewest guesses.
+func mostGuessable(runes []rune, inputs map[string]int) (float64, []match) {
+	n := len(runes)
+	byEnd := make([][]match, n)
+	for _, m := range matches(runes, inputs) {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+	for j := range n {
+		for i := 0; i <= j; i++ {
+			byEnd[j] = append(byEnd[j], match{
+				i: i, j: j,
+				pattern: patternBruteforce,
+				guesses: math.Pow(10, float64(j-i+1)),
+				token:   string(runes[i : j+1]),
+			})
+		}
+	}
+	for j := range byEnd {
+		for k := range byEnd[j] {
+			m := &byEnd[j][k]
+			if m.i == 0 && m.j == n-1 {
+				continue
+			}
+			// A part of a password is never trivial to guess.
+			if m.i == m.j {
+				m.guesses = max(m.guesses, 10)
+			} else {
+				m.guesses = max(m.guesses, 50)
+			}
+		}
+	}
+
+	// best[k][j] is the fewest guesses for the first j+1 runes with k
+	// matches, and last[k][j] the match ending it.
+	best := make([][]float64, n+1)
+	last := make([][]*match, n+1)
+	for k := range best {
+		best[k] = make([]float64, n)
+		last[k] = make([]*match, n)
+		for j := range best[k] {
+			best[k][j] = math.Inf(1)
+		}
+	}
+	for j := range n {
+		for idx := range byEnd[j] {
+			m := &byEnd[j][idx]
+			if m.i == 0 {
+				if m.guesses < best[1][j] {
+					best[1][j], last[1][j] = m.guesses, m
+				}
+				continue
+			}
+			for k := 2; k <= n; k++ {
+				if g := best[k-1][m.i-1] * m.guesses; g < best[k][j] {
+					best[k][j], last[k][j] = g, m
+				}
+			}
+		}
+	}
+
+	guesses, length := math.Inf(1), 0
+	for k := 1; k <= n; k++ {
+		if math.IsInf(best[k][n-1], 1) {
+			continue
+		}
+		g := factorial(k)*best[k][n-1] + math.Pow(minGuessesBeforeGrowingSequence, float64(k-1))
+		if g < guesses {
+			guesses, length = g, k
+		}
+	}
+	sequence := make([]match, length)
+	for k, j := length, n-1; k > 0; k-- {
+		m := last[k][j]
+		sequence[k-1] = *m
+		j = m.i - 1
+	}
+	return guesses, sequence
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+func matches(runes []rune, inputs map[string]int) []match {
+	var all []match
+	all = append(all, dictionaryMatches(runes, inputs)...)
+	all = append(all, sequenceMatches(runes)...)
+	all = append(all, repeatMatches(runes, inputs)...)
+	all = append(all, spatialMatches(runes)...)
+	all = append(all, yearMatches(runes)...)
+	return all
+}
+
+func rankWords(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, word := range words {
+		if _, ok := ranks[word]; !ok && word != "" {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}
+
+var l33tTable = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g',
+	'1': 'i', '!': 'i', '|': 'l', '0': 'o', '$': 's', '5': 's',
+	'+': 't', '7': 't', '2': 'z',
+}
+
+// dictionaryMatches finds common words and the user's details, also when
+// reversed or written with l33t substitutions.
+func dictionaryMatches(runes []rune, inputs map[string]int) []match {
+	var found []match
+	lower := []rune(strings.ToLower(string(runes)))
+	for i := range lower {
+		for j := i + 2; j < len(lower) && j-i < 32; j++ {
+			token := string(runes[i : j+1])
+			word := string(lower[i : j+1])
+			subs := 0
+			unl33t := []rune(word)
+			for k, r := range unl33t {
+				if sub, ok := l33tTable[r]; ok {
+					unl33t[k] = sub
+					subs++
+				}
+			}
+			candidates := []struct {
+				word     string
+				l33t     bool
+				reversed bool
+			}{
+				{word, false, false},
+				{reverse(word), false, true},
+				{string(unl33t), subs > 0, false},
+			}
+			for _, c := range candidates {
+				if c.l33t && c.word == word {
+					continue
+				}
+				p, rank := patternUserInput, inputs[c.word]
+				if rank == 0 {
+					p, rank = patternDictionary, commonRanks[c.word]
+				}
+				if rank == 0 {
+					continue
+				}
+				guesses := float64(rank) * uppercaseVariations(token)
+				if c.reversed {
+					guesses *= 2
+				}
+				if c.l33t {
+					guesses *= math.Pow(2, float64(subs))
+				}
+				found = append(found, match{
+					i: i, j: j,
+					pattern:  p,
+					guesses:  guesses,
+					token:    token,
+					rank:     rank,
+					l33t:     c.l33t,
+					reversed: c.reversed,
+				})
+			}
+		}
+	}
+	return found
+}
+
+// uppercaseVariations is how many ways of capitalizing a word an attacker
+// tries before the one used in token.
+func uppercaseVariations(token string) float64 {
+	var upper, lower int
+	runes := []rune(token)
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(runes[0]) || unicode.IsUpper(runes[len(runes)-1]))) {
+		return 2
+	}
+	variations := 0.0
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+func binomial(n, k int) float64 {
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r = r * float64(n-k+d) / float64(d)
+	}
+	return r
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// sequenceMatches finds runs such as abc, 4321 or ace of at least three
+// characters.
+func sequenceMatches(runes []rune) []match {
+	var found []match
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		for j+1 < len(runes) && runes[j+1]-runes[j] == delta {
+			j++
+		}
+		if j-i >= 2 && delta != 0 && delta >= -5 && delta <= 5 {
+			first := runes[i]
+			var base float64
+			switch {
+			case strings.ContainsRune("aAzZ019", first):
+				base = 4
+			case unicode.IsDigit(first):
+				base = 10
+			default:
+				base = 26
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			found = append(found, match{
+				i: i, j: j,
+				pattern: patternSequence,
+				guesses: base * float64(j-i+1),
+				token:   string(runes[i : j+1]),
+			})
+			i = j
+			continue
+		}
+		i++
+	}
+	return found
+}
+
+// repeatMatches finds a character or a group of them repeated, such as
+// aaa or abcabc.
+func repeatMatches(runes []rune, inputs map[string]int) []match {
+	var found []match
+	for i := range runes {
+		for size := 1; i+2*size <= len(runes); size++ {
+			unit := runes[i : i+size]
+			count := 1
+			for end := i + (count+1)*size; end <= len(runes) && string(runes[end-size:end]) == string(unit); end += size {
+				count++
+			}
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			unitGuesses, _ := mostGuessable(unit, inputs)
+			found = append(found, match{
+				i: i, j: i + count*size - 1,
+				pattern: patternRepeat,
+				guesses: unitGuesses * float64(count),
+				token:   string(runes[i : i+count*size]),
+				unit:    string(unit),
+			})
+		}
+	}
+	return found
+}
+
+// keyboard holds the rows of a QWERTY keyboard, shifted as they are on the
+// keyboard, with the shifted characters of each key.
+var keyboard = []struct {
+	keys, shifted string
+	offset        float64
+}{
+	{"`1234567890-=", "~!@#$%^&*()_+", 0},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|", 0.5},
+	{"asdfghjkl;'", "ASDFGHJKL:\"", 0.75},
+	{"zxcvbnm,./", "ZXCVBNM<>?", 1.25},
+}
+
+type keyPosition struct {
+	row int
+	x   float64
+}
+
+var keyPositions = func() map[rune]keyPosition {
+	positions := make(map[rune]keyPosition)
+	for row, r := range keyboard {
+		for col, key := range r.keys {
+			positions[key] = keyPosition{row, float64(col) + r.offset}
+		}
+		for col, key := range r.shifted {
+			positions[key] = keyPosition{row, float64(col) + r.offset}
+		}
+	}
+	return positions
+}()
+
+// spatialMatches finds runs of at least four neighbouring keys, such as
+// qwerty or zaq1.
+func spatialMatches(runes []rune) []match {
+	var found []match
+	adjacent := func(a, b rune) (keyPosition, bool) {
+		pa, okA := keyPositions[a]
+		pb, okB := keyPositions[b]
+		if !okA || !okB || a == b {
+			return keyPosition{}, false
+		}
+		step := keyPosition{pb.row - pa.row, pb.x - pa.x}
+		return step, step.row >= -1 && step.row <= 1 && math.Abs(step.x) <= 1 && (step.row != 0 || step.x != 0)
+	}
+	for i := 0; i < len(runes)-3; {
+		j, turns := i, 0
+		var direction keyPosition
+		for j+1 < len(runes) {
+			step, ok := adjacent(runes[j], runes[j+1])
+			if !ok {
+				break
+			}
+			if j == i || step != direction {
+				turns++
+				direction = step
+			}
+			j++
+		}
+		if j-i >= 3 {
+			found = append(found, match{
+				i: i, j: j,
+				pattern: patternSpatial,
+				guesses: float64(len(keyPositions)) * math.Pow(4.6, float64(turns)) * float64(j-i+1),
+				token:   string(runes[i : j+1]),
+				turns:   turns,
+			})
+			i = j
+			continue
+		}
+		i++
+	}
+	return found
+}
+
+// yearMatches finds years from 1900 to 2049.
+func yearMatches(runes []rune) []match {
+	var found []match
+	now := time.Now().Year()
+	for i := 0; i+4 <= len(runes); i++ {
+		token := string(runes[i : i+4])
+		year, err := strconv.Atoi(token)
+		if err != nil || strings.Trim(token, "0123456789") != "" || year < 1900 || year > 2049 {
+			continue
+		}
+		found = append(found, match{
+			i: i, j: i + 3,
+			pattern: patternYear,
+			guesses: max(math.Abs(float64(year-now)), 20),
+			token:   token,
+		})
+	}
+	return found
+}
+
+const defaultSuggestion = "Add another word or two. Uncommon words are better"
+
+// feedback explains the longest match of the sequence, which is the part
+// of the password that weakens it the most.
+func feedback(sequence []match) (string, []string) {
+	var longest *match
+	for k := range sequence {
+		if sequence[k].pattern == patternBruteforce {
+			continue
+		}
+		if longest == nil || sequence[k].j-sequence[k].i > longest.j-longest.i {
+			longest = &sequence[k]
+		}
+	}
+	suggestions := []string{defaultSuggestion}
+	if longest == nil {
+		return "", suggestions
+	}
+	switch longest.pattern {
+	case patternDictionary, patternUserInput:
+		var warning string
+		switch {
+		case longest.pattern == patternUserInput:
+			warning = "Avoid using your name, username or email"
+		case longest.l33t || longest.reversed:
+			warning = "This is similar to a commonly used password"
+		case len(sequence) == 1 && longest.rank <= 10:
+			warning = "This is a top-10 common password"
+		case len(sequence) == 1 && longest.rank <= 100:
+			warning = "This is a top-100 common password"
+		case len(sequence) == 1:
+			warning = "This is a very common password"
+		default:
+			warning = "Common words are easy to guess"
+		}
+		runes := []rune(longest.token)
+		switch {
+		case strings.ToUpper(longest.token) == longest.token && strings.ToLower(longest.token) != longest.token:
+			suggestions = append(suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+		case unicode.IsUpper(runes[0]):
+			suggestions = append(suggestions, "Capitalization doesn't help very much")
+		}
+		if longest.reversed {
+			suggestions = append(suggestions, "Reversed words aren't much harder to guess")
+		}
+		if longest.l33t {
+			suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+		}
+		return warning, suggestions
+	case patternSpatial:
+		warning := "Short keyboard patterns are easy to guess"
+		if longest.turns == 1 {
+			warning = "Straight rows of keys are easy to guess"
+		}
+		return warning, append(suggestions, "Use a longer keyboard pattern with more turns")
+	case patternRepeat:
+		warning := fmt.Sprintf("Repeats like %q are only slightly harder to guess than %q", longest.token, longest.unit)
+		if len([]rune(longest.unit)) == 1 {
+			warning = fmt.Sprintf("Repeats like %q are easy to guess", longest.token)
+		}
+		return warning, append(suggestions, "Avoid repeated words and characters")
+	case patternSequence:
+		return "Sequences like abc or 6543 are easy to guess", append(suggestions, "Avoid sequences")
+	case patternYear:
+		return "Recent years are easy to guess", append(suggestions, "Avoid recent years", "Avoid years that are associated with you")
+	}
+	return "", suggestions
+}
@@ -0,0 +1,85 @@
+package passwd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		inputs   []string
+		min, max int
+		warning  string // a part of the warning
+	}{
+		{"empty", "", nil, 0, 0, "empty"},
+		{"top common password", "password", nil, 0, 0, "top-10 common password"},
+		{"digits", "123456", nil, 0, 0, "top-10 common password"},
+		{"keyboard row", "qwerty", nil, 0, 0, "common password"},
+		{"l33t", "P@ssw0rd", nil, 0, 0, "similar to a commonly used password"},
+		{"reversed", "drowssap", nil, 0, 0, "similar to a commonly used password"},
+		{"sequence", "abcdefgh", nil, 0, 0, "Sequences"},
+		{"repeat", "aaaaaaaa", nil, 0, 0, "Repeats"},
+		{"year", "1990", nil, 0, 0, "years"},
+		{"decorated common word", "Password1!", nil, 0, 1, "Common words"},
+		{"name of the user", "anasilva", []string{"Ana", "Silva"}, 0, 1, "your name"},
+		{"passphrase", "correct horse battery staple", nil, 4, 4, ""},
+		{"random", "x7#Kq!9vLm2$Wz", nil, 4, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := EstimateStrength(tt.password, tt.inputs...)
+			if s.Score < tt.min || s.Score > tt.max {
+				t.Errorf("EstimateStrength(%q).Score = %d, want %d to %d", tt.password, s.Score, tt.min, tt.max)
+			}
+			if !strings.Contains(s.Warning, tt.warning) {
+				t.Errorf("EstimateStrength(%q).Warning = %q, want it to contain %q", tt.password, s.Warning, tt.warning)
+			}
+			if s.Score < 3 && len(s.Suggestions) == 0 {
+				t.Errorf("EstimateStrength(%q).Suggestions is empty", tt.password)
+			}
+		})
+	}
+}
+
+func TestEstimateStrengthScoresPrefix(t *testing.T) {
+	long := strings.Repeat("ab1!", 1000)
+	got, want := EstimateStrength(long), EstimateStrength(long[:maxStrengthRunes])
+	if got.Score != want.Score || got.Guesses != want.Guesses {
+		t.Errorf("EstimateStrength() of a long password = %v, want the estimate of its prefix %v", got, want)
+	}
+}
+
+func TestPolicyValidateStrength(t *testing.T) {
+	breached := NewBloomFilter(10, 0.01)
+	breached.Add("x7#Kq!9vLm2$Wz")
+
+	tests := []struct {
+		name     string
+		password string
+		breached Corpus
+		problem  string // a part of the error, empty for none
+	}{
+		{"weak", "Password1!", nil, "is too weak"},
+		{"name of the user", "AnaSilva2024!", nil, "must not contain"},
+		{"strong", "x7#Kq!9vLm2$Wz", nil, ""},
+		{"breached", "x7#Kq!9vLm2$Wz", breached, "appears in a data breach"},
+	}
+	policy := Policy{MinScore: 3, ForbidPersonalInfo: true}
+	user := UserInfo{FirstName: "Ana", LastName: "Silva", Username: "ana.silva", Email: "ana@example.com"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, user, tt.breached)
+			if tt.problem == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.problem)
+			}
+		})
+	}
+}
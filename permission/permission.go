@@ -21,4 +21,59 @@ const (
 	PermissionViewTenant   PermissionCode = "view_tenant"
 	PermissionCreateTenant PermissionCode = "create_tenant"
 	PermissionUpdateTenant PermissionCode = "update_tenant"
+	// Policies
+	PermissionViewPolicy   PermissionCode = "view_policy"
+	PermissionCreatePolicy PermissionCode = "create_policy"
+	PermissionUpdatePolicy PermissionCode = "update_policy"
+	// Relations
+	PermissionViewRelation   PermissionCode = "view_relation"
+	PermissionUpdateRelation PermissionCode = "update_relation"
+	// Authorization
+	PermissionCheckAuthz PermissionCode = "check_authz"
+	// Access requests
+	PermissionApproveAccessRequest PermissionCode = "approve_access_request"
+	// Groups
+	PermissionViewGroup   PermissionCode = "view_group"
+	PermissionCreateGroup PermissionCode = "create_group"
+	PermissionUpdateGroup PermissionCode = "update_group"
+	PermissionDeleteGroup PermissionCode = "delete_group"
 )
+
+// Builtin lists the codes the module relies on. They are seeded by New and
+// never pruned by a policy file sync.
+var Builtin = []Definition{
+	// Admin
+	{Code: PermissionAdmin, Description: "Full administrative access", Group: "admin"},
+	// Users
+	{Code: PermissionViewUser, Description: "View users", Group: "users"},
+	{Code: PermissionCreateUser, Description: "Create users", Group: "users"},
+	{Code: PermissionUpdateUser, Description: "Update users", Group: "users"},
+	// Permissions
+	{Code: PermissionViewPermission, Description: "View permissions", Group: "permissions"},
+	{Code: PermissionCreatePermission, Description: "Create permissions", Group: "permissions"},
+	{Code: PermissionUpdatePermission, Description: "Update permissions", Group: "permissions"},
+	// Roles
+	{Code: PermissionViewRole, Description: "View roles", Group: "roles"},
+	{Code: PermissionCreateRole, Description: "Create roles", Group: "roles"},
+	{Code: PermissionUpdateRole, Description: "Update roles", Group: "roles"},
+	// Tenants
+	{Code: PermissionViewTenant, Description: "View tenants", Group: "tenants"},
+	{Code: PermissionCreateTenant, Description: "Create tenants", Group: "tenants"},
+	{Code: PermissionUpdateTenant, Description: "Update tenants", Group: "tenants"},
+	// Policies
+	{Code: PermissionViewPolicy, Description: "View access policies", Group: "policies"},
+	{Code: PermissionCreatePolicy, Description: "Create access policies", Group: "policies"},
+	{Code: PermissionUpdatePolicy, Description: "Update access policies", Group: "policies"},
+	// Relations
+	{Code: PermissionViewRelation, Description: "View and check relation tuples", Group: "relations"},
+	{Code: PermissionUpdateRelation, Description: "Write and delete relation tuples", Group: "relations"},
+	// Authorization
+	{Code: PermissionCheckAuthz, Description: "Check the access of other subjects", Group: "authorization"},
+	// Access requests
+	{Code: PermissionApproveAccessRequest, Description: "Review access requests", Group: "access_requests"},
+	// Groups
+	{Code: PermissionViewGroup, Description: "View groups", Group: "groups"},
+	{Code: PermissionCreateGroup, Description: "Create groups", Group: "groups"},
+	{Code: PermissionUpdateGroup, Description: "Update groups and their members", Group: "groups"},
+	{Code: PermissionDeleteGroup, Description: "Delete groups", Group: "groups"},
+}
@@ -0,0 +1,53 @@
+package permission
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+var codePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Definition describes a permission code. When DefaultRoles is set, the
+// permission is granted to those roles the first time it is seeded; the
+// roles are created if they don't exist.
+type Definition struct {
+	Code         PermissionCode
+	Description  string
+	Group        string
+	DefaultRoles []string
+}
+
+// Registry collects the permission codes an application defines on top of
+// the builtin ones.
+//
+//	config.Permissions.Register("view_invoice", "View invoices", "billing", "billing_clerk")
+type Registry struct {
+	definitions []Definition
+}
+
+func (r *Registry) Register(code PermissionCode, description, group string, defaultRoles ...string) *Registry {
+	r.definitions = append(r.definitions, Definition{
+		Code:         code,
+		Description:  description,
+		Group:        group,
+		DefaultRoles: defaultRoles,
+	})
+	return r
+}
+
+// Definitions returns the builtin definitions followed by the registered
+// ones, or an error when a code is invalid or registered twice.
+func (r *Registry) Definitions() ([]Definition, error) {
+	all := slices.Clone(Builtin)
+	for _, d := range r.definitions {
+		if !codePattern.MatchString(string(d.Code)) || len(d.Code) > 50 {
+			return nil, fmt.Errorf("invalid permission code %q", d.Code)
+		}
+		if slices.ContainsFunc(all, func(b Definition) bool { return b.Code == d.Code }) {
+			return nil, fmt.Errorf("permission code %q is already registered", d.Code)
+		}
+		all = append(all, d)
+	}
+	return all, nil
+}
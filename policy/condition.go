@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Operator string
+
+const (
+	OperatorEq         Operator = "eq"
+	OperatorNe         Operator = "ne"
+	OperatorIn         Operator = "in"
+	OperatorNotIn      Operator = "not_in"
+	OperatorContains   Operator = "contains"
+	OperatorIntersects Operator = "intersects"
+	OperatorGt         Operator = "gt"
+	OperatorGte        Operator = "gte"
+	OperatorLt         Operator = "lt"
+	OperatorLte        Operator = "lte"
+)
+
+var operators = []Operator{
+	OperatorEq, OperatorNe, OperatorIn, OperatorNotIn, OperatorContains,
+	OperatorIntersects, OperatorGt, OperatorGte, OperatorLt, OperatorLte,
+}
+
+// Condition compares the attribute at Attribute with either the literal
+// Value or, when Ref is set, with the attribute at Ref. Attribute paths are
+// written as "subject.tenants", "resource.tenants" or "context.hour".
+type Condition struct {
+	Attribute string   `json:"attribute"`
+	Operator  Operator `json:"operator"`
+	Value     any      `json:"value,omitempty"`
+	Ref       string   `json:"ref,omitempty"`
+}
+
+func (c Condition) Validate() error {
+	if _, _, err := splitPath(c.Attribute); err != nil {
+		return err
+	}
+	if c.Ref != "" {
+		if _, _, err := splitPath(c.Ref); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(operators, c.Operator) {
+		return fmt.Errorf("invalid operator %q", c.Operator)
+	}
+	return nil
+}
+
+func (c Condition) Holds(req Request) bool {
+	left, ok := req.Lookup(c.Attribute)
+	if !ok {
+		return false
+	}
+	right := c.Value
+	if c.Ref != "" {
+		if right, ok = req.Lookup(c.Ref); !ok {
+			return false
+		}
+	}
+
+	switch c.Operator {
+	case OperatorEq:
+		return equal(left, right)
+	case OperatorNe:
+		return !equal(left, right)
+	case OperatorIn:
+		return containsValue(toSlice(right), left)
+	case OperatorNotIn:
+		return !containsValue(toSlice(right), left)
+	case OperatorContains:
+		return containsValue(toSlice(left), right)
+	case OperatorIntersects:
+		for _, v := range toSlice(left) {
+			if containsValue(toSlice(right), v) {
+				return true
+			}
+		}
+		return false
+	case OperatorGt, OperatorGte, OperatorLt, OperatorLte:
+		l, lok := toFloat(left)
+		r, rok := toFloat(right)
+		if !lok || !rok {
+			return false
+		}
+		switch c.Operator {
+		case OperatorGt:
+			return l > r
+		case OperatorGte:
+			return l >= r
+		case OperatorLt:
+			return l < r
+		default:
+			return l <= r
+		}
+	}
+	return false
+}
+
+// Lookup resolves an attribute path against the request.
+func (req Request) Lookup(path string) (any, bool) {
+	namespace, key, err := splitPath(path)
+	if err != nil {
+		return nil, false
+	}
+	var attrs Attributes
+	switch namespace {
+	case "subject":
+		attrs = req.Subject
+	case "resource":
+		attrs = req.Object
+	case "context":
+		attrs = req.Context
+	}
+	v, ok := attrs[key]
+	return v, ok
+}
+
+func splitPath(path string) (string, string, error) {
+	namespace, key, ok := strings.Cut(path, ".")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid attribute %q", path)
+	}
+	switch namespace {
+	case "subject", "resource", "context":
+		return namespace, key, nil
+	}
+	return "", "", fmt.Errorf("invalid attribute namespace %q", namespace)
+}
+
+func equal(a, b any) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toSlice(v any) []any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []any{v}
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case bool:
+		return 0, false
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/go-gorote/auth/secret"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResourceResolver extracts the attributes of the resource addressed by the
+// request, e.g. by loading it from the path parameters.
+type ResourceResolver func(*fiber.Ctx) (Attributes, error)
+
+func Subject(claims *secret.JwtClaims) Attributes {
+	return Attributes{
+		"id":            claims.ID,
+		"is_super_user": claims.IsSuperUser,
+		"permissions":   claims.Permissions,
+		"tenants":       claims.Tenants,
+	}
+}
+
+func Context(ctx *fiber.Ctx) Attributes {
+	now := time.Now()
+	return Attributes{
+		"time":    now.Unix(),
+		"hour":    now.Hour(),
+		"weekday": int(now.Weekday()),
+		"ip":      ctx.IP(),
+		"method":  ctx.Method(),
+		"path":    ctx.Path(),
+	}
+}
+
+// Middleware authorizes the request against the stored policies. It must run
+// after the JWT middleware, since it reads the claims from the context.
+func Middleware(authorizer Authorizer, action, resource string, resolve ResourceResolver) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		claims, ok := ctx.Locals("claimsData").(*secret.JwtClaims)
+		if !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "missing token claims")
+		}
+		object := Attributes{}
+		if resolve != nil {
+			attrs, err := resolve(ctx)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			object = attrs
+		}
+		decision, err := authorizer.Authorize(ctx.UserContext(), Request{
+			Action:   action,
+			Resource: resource,
+			Subject:  Subject(claims),
+			Object:   object,
+			Context:  Context(ctx),
+		})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		if !decision.Allowed {
+			return fiber.NewError(fiber.StatusForbidden, decision.Reason)
+		}
+		return ctx.Next()
+	}
+}
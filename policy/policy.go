@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// Attributes holds the values a condition can refer to. Keys are looked up
+// under the "subject", "resource" and "context" namespaces of a Request.
+type Attributes map[string]any
+
+type Rule struct {
+	Name       string
+	Effect     Effect
+	Actions    []string
+	Resource   string
+	Conditions []Condition
+}
+
+type Request struct {
+	Action   string
+	Resource string
+	Subject  Attributes
+	Object   Attributes
+	Context  Attributes
+}
+
+type Decision struct {
+	Allowed    bool   `json:"allowed"`
+	Applicable bool   `json:"applicable"`
+	Policy     string `json:"policy,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// Authorizer decides whether a request is allowed by the stored policies.
+type Authorizer interface {
+	Authorize(context.Context, Request) (Decision, error)
+}
+
+// Targets reports whether the rule is written for the action and resource of
+// the request. "*" matches any action or resource.
+func (r Rule) Targets(req Request) bool {
+	if r.Resource != "*" && r.Resource != req.Resource {
+		return false
+	}
+	return slices.Contains(r.Actions, "*") || slices.Contains(r.Actions, req.Action)
+}
+
+func (r Rule) Validate() error {
+	if r.Effect != EffectAllow && r.Effect != EffectDeny {
+		return fmt.Errorf("invalid effect %q", r.Effect)
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("policy must have at least one action")
+	}
+	if r.Resource == "" {
+		return fmt.Errorf("policy must have a resource")
+	}
+	for _, c := range r.Conditions {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate applies the rules to the request with deny-overrides semantics.
+// When no rule targets the request the decision is not applicable and the
+// caller should rely on role checks alone. When rules target the request,
+// at least one allow rule must match and no deny rule may match.
+func Evaluate(req Request, rules []Rule) Decision {
+	targeted := false
+	var allow *Rule
+	for i := range rules {
+		rule := rules[i]
+		if !rule.Targets(req) {
+			continue
+		}
+		targeted = true
+		if !rule.Matches(req) {
+			continue
+		}
+		if rule.Effect == EffectDeny {
+			return Decision{
+				Allowed:    false,
+				Applicable: true,
+				Policy:     rule.Name,
+				Reason:     fmt.Sprintf("denied by policy %s", rule.Name),
+			}
+		}
+		if allow == nil {
+			allow = &rules[i]
+		}
+	}
+	if !targeted {
+		return Decision{Allowed: true, Applicable: false, Reason: "no policy applies"}
+	}
+	if allow == nil {
+		return Decision{Allowed: false, Applicable: true, Reason: "no policy allows the request"}
+	}
+	return Decision{
+		Allowed:    true,
+		Applicable: true,
+		Policy:     allow.Name,
+		Reason:     fmt.Sprintf("allowed by policy %s", allow.Name),
+	}
+}
+
+func (r Rule) Matches(req Request) bool {
+	for _, c := range r.Conditions {
+		if !c.Holds(req) {
+			return false
+		}
+	}
+	return true
+}
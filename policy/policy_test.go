@@ -0,0 +1,136 @@
+package policy
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	sameTenant := Rule{
+		Name:     "same-tenant",
+		Effect:   EffectAllow,
+		Actions:  []string{"read", "update"},
+		Resource: "invoice",
+		Conditions: []Condition{
+			{Attribute: "subject.tenants", Operator: OperatorIntersects, Ref: "resource.tenants"},
+		},
+	}
+	afterHours := Rule{
+		Name:     "after-hours",
+		Effect:   EffectDeny,
+		Actions:  []string{"*"},
+		Resource: "*",
+		Conditions: []Condition{
+			{Attribute: "context.hour", Operator: OperatorGte, Value: 22},
+		},
+	}
+	rules := []Rule{sameTenant, afterHours}
+	request := func(action, resource, tenant string, hour int) Request {
+		return Request{
+			Action:   action,
+			Resource: resource,
+			Subject:  Attributes{"tenants": []string{"acme", "globex"}},
+			Object:   Attributes{"tenants": []string{tenant}},
+			Context:  Attributes{"hour": hour},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		req        Request
+		allowed    bool
+		applicable bool
+		policy     string
+	}{
+		{"allowed", request("read", "invoice", "acme", 10), true, true, "same-tenant"},
+		{"no allow rule matches", request("read", "invoice", "initech", 10), false, true, ""},
+		{"deny overrides allow", request("read", "invoice", "acme", 23), false, true, "after-hours"},
+		{"only the deny rule targets", request("delete", "invoice", "acme", 10), false, true, ""},
+		{"deny rule targets without matching", Request{Action: "read", Resource: "report"}, false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(tt.req, rules)
+			if d.Allowed != tt.allowed || d.Applicable != tt.applicable || d.Policy != tt.policy {
+				t.Errorf("Evaluate() = %+v, want allowed %v, applicable %v, policy %q", d, tt.allowed, tt.applicable, tt.policy)
+			}
+		})
+	}
+	if d := Evaluate(request("read", "report", "acme", 10), []Rule{sameTenant}); !d.Allowed || d.Applicable {
+		t.Errorf("Evaluate() with no rule targeting = %+v, want allowed and not applicable", d)
+	}
+}
+
+func TestConditionHolds(t *testing.T) {
+	req := Request{
+		Subject: Attributes{
+			"id":      "u1",
+			"level":   3,
+			"tenants": []string{"acme", "globex"},
+			"roles":   []any{"admin"},
+		},
+		Object:  Attributes{"owner": "u1", "tenants": []string{"initech"}, "amount": "150.5"},
+		Context: Attributes{"ip": "10.0.0.1"},
+	}
+	tests := []struct {
+		name string
+		c    Condition
+		want bool
+	}{
+		{"eq", Condition{Attribute: "subject.id", Operator: OperatorEq, Value: "u1"}, true},
+		{"eq numbers of other types", Condition{Attribute: "subject.level", Operator: OperatorEq, Value: 3.0}, true},
+		{"eq ref", Condition{Attribute: "subject.id", Operator: OperatorEq, Ref: "resource.owner"}, true},
+		{"ne", Condition{Attribute: "subject.id", Operator: OperatorNe, Value: "u2"}, true},
+		{"in", Condition{Attribute: "context.ip", Operator: OperatorIn, Value: []any{"10.0.0.1", "10.0.0.2"}}, true},
+		{"not in", Condition{Attribute: "context.ip", Operator: OperatorNotIn, Value: []any{"10.0.0.1"}}, false},
+		{"contains", Condition{Attribute: "subject.tenants", Operator: OperatorContains, Value: "acme"}, true},
+		{"contains of any slice", Condition{Attribute: "subject.roles", Operator: OperatorContains, Value: "admin"}, true},
+		{"intersects", Condition{Attribute: "subject.tenants", Operator: OperatorIntersects, Ref: "resource.tenants"}, false},
+		{"gt", Condition{Attribute: "subject.level", Operator: OperatorGt, Value: 2}, true},
+		{"gte", Condition{Attribute: "subject.level", Operator: OperatorGte, Value: 3}, true},
+		{"lt string number", Condition{Attribute: "resource.amount", Operator: OperatorLt, Value: 200}, true},
+		{"lte", Condition{Attribute: "subject.level", Operator: OperatorLte, Value: 2}, false},
+		{"compare a non number", Condition{Attribute: "subject.id", Operator: OperatorGt, Value: 1}, false},
+		{"missing attribute", Condition{Attribute: "subject.missing", Operator: OperatorNe, Value: "x"}, false},
+		{"missing ref", Condition{Attribute: "subject.id", Operator: OperatorNe, Ref: "resource.missing"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Holds(req); got != tt.want {
+				t.Errorf("Holds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	valid := Rule{Name: "r", Effect: EffectAllow, Actions: []string{"read"}, Resource: "invoice"}
+	tests := []struct {
+		name    string
+		mutate  func(*Rule)
+		wantErr bool
+	}{
+		{"valid", func(*Rule) {}, false},
+		{"invalid effect", func(r *Rule) { r.Effect = "maybe" }, true},
+		{"no actions", func(r *Rule) { r.Actions = nil }, true},
+		{"no resource", func(r *Rule) { r.Resource = "" }, true},
+		{"invalid namespace", func(r *Rule) {
+			r.Conditions = []Condition{{Attribute: "user.id", Operator: OperatorEq}}
+		}, true},
+		{"attribute without key", func(r *Rule) {
+			r.Conditions = []Condition{{Attribute: "subject.", Operator: OperatorEq}}
+		}, true},
+		{"invalid ref", func(r *Rule) {
+			r.Conditions = []Condition{{Attribute: "subject.id", Operator: OperatorEq, Ref: "owner"}}
+		}, true},
+		{"invalid operator", func(r *Rule) {
+			r.Conditions = []Condition{{Attribute: "subject.id", Operator: "like"}}
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.mutate(&rule)
+			if err := rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
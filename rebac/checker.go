@@ -0,0 +1,239 @@
+package rebac
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+const defaultMaxDepth = 25
+
+// Store reads relation tuples and role memberships.
+type Store interface {
+	Tuples(ctx context.Context, namespace, objectID, relation string) ([]Tuple, error)
+	Objects(ctx context.Context, namespace string) ([]string, error)
+	RolesOf(ctx context.Context, userID string) ([]string, error)
+}
+
+type Checker struct {
+	Store      Store
+	Namespaces []Namespace
+	MaxDepth   int
+}
+
+// Node is a level of the userset tree returned by Expand.
+type Node struct {
+	Operation string    `json:"operation"`
+	Object    string    `json:"object"`
+	Relation  string    `json:"relation"`
+	Subjects  []Subject `json:"subjects,omitempty"`
+	Children  []*Node   `json:"children,omitempty"`
+}
+
+func (c *Checker) namespace(name string) Namespace {
+	for _, n := range c.Namespaces {
+		if n.Name == name {
+			return n
+		}
+	}
+	return Namespace{Name: name}
+}
+
+func (c *Checker) maxDepth() int {
+	if c.MaxDepth > 0 {
+		return c.MaxDepth
+	}
+	return defaultMaxDepth
+}
+
+// Validate reports an error when the namespace is configured and does not
+// declare the relation. Unconfigured namespaces accept any relation.
+func (c *Checker) Validate(namespace, relation string) error {
+	for _, n := range c.Namespaces {
+		if n.Name == namespace {
+			return n.Validate(relation)
+		}
+	}
+	return nil
+}
+
+// Check reports whether the subject has the relation on the object. The
+// subject must be a user or a role; a user also matches tuples granted to
+// any of their roles.
+func (c *Checker) Check(ctx context.Context, namespace, objectID, relation string, subject Subject) (bool, error) {
+	matches, err := c.matches(ctx, subject)
+	if err != nil {
+		return false, err
+	}
+	return c.check(ctx, namespace, objectID, relation, matches, visited{}, 0)
+}
+
+// visited holds the (namespace, object, relation) tuples a check or expand
+// went through, so that cyclic usersets end instead of exceeding the max
+// depth.
+type visited map[[3]string]bool
+
+// visit reports whether the tuple was visited already, marking it if not.
+func (v visited) visit(namespace, objectID, relation string) bool {
+	key := [3]string{namespace, objectID, relation}
+	if v[key] {
+		return true
+	}
+	v[key] = true
+	return false
+}
+
+// matches returns the direct subjects that stand for the given subject.
+func (c *Checker) matches(ctx context.Context, subject Subject) ([]Subject, error) {
+	if subject.Type != SubjectUser && subject.Type != SubjectRole {
+		return nil, fmt.Errorf("subject must be a user or a role")
+	}
+	matches := []Subject{{Type: subject.Type, ID: subject.ID}}
+	if subject.Type == SubjectUser {
+		roles, err := c.Store.RolesOf(ctx, subject.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			matches = append(matches, Subject{Type: SubjectRole, ID: role})
+		}
+	}
+	return matches, nil
+}
+
+// check evaluates the relation depth first. A tuple visited before either
+// is being evaluated further up, which makes a cycle, or didn't match, so
+// it evaluates to false again.
+func (c *Checker) check(ctx context.Context, namespace, objectID, relation string, matches []Subject, seen visited, depth int) (bool, error) {
+	if seen.visit(namespace, objectID, relation) {
+		return false, nil
+	}
+	if depth > c.maxDepth() {
+		return false, fmt.Errorf("relation check exceeded max depth of %d", c.maxDepth())
+	}
+	for _, userset := range c.namespace(namespace).rewrite(relation).Union {
+		switch {
+		case userset.This:
+			tuples, err := c.Store.Tuples(ctx, namespace, objectID, relation)
+			if err != nil {
+				return false, err
+			}
+			for _, t := range tuples {
+				if !t.Subject.IsUserset() {
+					if slices.Contains(matches, t.Subject) {
+						return true, nil
+					}
+					continue
+				}
+				ok, err := c.check(ctx, t.Subject.Type, t.Subject.ID, t.Subject.Relation, matches, seen, depth+1)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+		case userset.ComputedUserset != "":
+			ok, err := c.check(ctx, namespace, objectID, userset.ComputedUserset, matches, seen, depth+1)
+			if err != nil || ok {
+				return ok, err
+			}
+		case userset.TupleToUserset != nil:
+			tuples, err := c.Store.Tuples(ctx, namespace, objectID, userset.TupleToUserset.Tupleset)
+			if err != nil {
+				return false, err
+			}
+			for _, t := range tuples {
+				ok, err := c.check(ctx, t.Subject.Type, t.Subject.ID, userset.TupleToUserset.ComputedUserset, matches, seen, depth+1)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// Expand returns the tree of usersets that grant the relation on the object.
+func (c *Checker) Expand(ctx context.Context, namespace, objectID, relation string) (*Node, error) {
+	return c.expand(ctx, namespace, objectID, relation, visited{}, 0)
+}
+
+// expand builds the tree depth first. A tuple already on the path to the
+// node makes a cycle, returned as a "cycle" leaf.
+func (c *Checker) expand(ctx context.Context, namespace, objectID, relation string, path visited, depth int) (*Node, error) {
+	object := fmt.Sprintf("%s:%s", namespace, objectID)
+	if path.visit(namespace, objectID, relation) {
+		return &Node{Operation: "cycle", Object: object, Relation: relation}, nil
+	}
+	defer delete(path, [3]string{namespace, objectID, relation})
+	if depth > c.maxDepth() {
+		return nil, fmt.Errorf("relation expand exceeded max depth of %d", c.maxDepth())
+	}
+	root := &Node{Operation: "union", Object: object, Relation: relation}
+	for _, userset := range c.namespace(namespace).rewrite(relation).Union {
+		switch {
+		case userset.This:
+			tuples, err := c.Store.Tuples(ctx, namespace, objectID, relation)
+			if err != nil {
+				return nil, err
+			}
+			leaf := &Node{Operation: "this", Object: object, Relation: relation}
+			for _, t := range tuples {
+				if !t.Subject.IsUserset() {
+					leaf.Subjects = append(leaf.Subjects, t.Subject)
+					continue
+				}
+				child, err := c.expand(ctx, t.Subject.Type, t.Subject.ID, t.Subject.Relation, path, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				leaf.Children = append(leaf.Children, child)
+			}
+			root.Children = append(root.Children, leaf)
+		case userset.ComputedUserset != "":
+			child, err := c.expand(ctx, namespace, objectID, userset.ComputedUserset, path, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			child.Operation = "computed_userset"
+			root.Children = append(root.Children, child)
+		case userset.TupleToUserset != nil:
+			tuples, err := c.Store.Tuples(ctx, namespace, objectID, userset.TupleToUserset.Tupleset)
+			if err != nil {
+				return nil, err
+			}
+			node := &Node{Operation: "tuple_to_userset", Object: object, Relation: userset.TupleToUserset.Tupleset}
+			for _, t := range tuples {
+				child, err := c.expand(ctx, t.Subject.Type, t.Subject.ID, userset.TupleToUserset.ComputedUserset, path, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				node.Children = append(node.Children, child)
+			}
+			root.Children = append(root.Children, node)
+		}
+	}
+	return root, nil
+}
+
+// ListObjects returns the ids of the objects in the namespace on which the
+// subject has the relation.
+func (c *Checker) ListObjects(ctx context.Context, namespace, relation string, subject Subject) ([]string, error) {
+	matches, err := c.matches(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.Store.Objects(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	res := []string{}
+	for _, objectID := range objects {
+		ok, err := c.check(ctx, namespace, objectID, relation, matches, visited{}, 0)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, objectID)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,163 @@
+package rebac
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// memoryStore keeps tuples in memory for the tests.
+type memoryStore struct {
+	tuples []Tuple
+	roles  map[string][]string
+}
+
+func (m *memoryStore) Tuples(_ context.Context, namespace, objectID, relation string) ([]Tuple, error) {
+	var res []Tuple
+	for _, t := range m.tuples {
+		if t.Namespace == namespace && t.ObjectID == objectID && t.Relation == relation {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryStore) Objects(_ context.Context, namespace string) ([]string, error) {
+	var res []string
+	for _, t := range m.tuples {
+		if t.Namespace == namespace {
+			res = append(res, t.ObjectID)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryStore) RolesOf(_ context.Context, userID string) ([]string, error) {
+	return m.roles[userID], nil
+}
+
+func tuple(t *testing.T, object, relation, subject string) Tuple {
+	t.Helper()
+	o, err := ParseSubject(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ParseSubject(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Tuple{Namespace: o.Type, ObjectID: o.ID, Relation: relation, Subject: s}
+}
+
+var documents = Namespace{
+	Name: "doc",
+	Relations: map[string]Rewrite{
+		"owner":  {},
+		"parent": {},
+		"editor": {Union: []Userset{{This: true}, {ComputedUserset: "owner"}}},
+		"viewer": {Union: []Userset{
+			{This: true},
+			{ComputedUserset: "editor"},
+			{TupleToUserset: &TupleToUserset{Tupleset: "parent", ComputedUserset: "viewer"}},
+		}},
+	},
+}
+
+// cyclic declares relations that compute each other.
+var cyclic = Namespace{
+	Name: "loop",
+	Relations: map[string]Rewrite{
+		"a": {Union: []Userset{{This: true}, {ComputedUserset: "b"}}},
+		"b": {Union: []Userset{{ComputedUserset: "a"}}},
+	},
+}
+
+func TestCheck(t *testing.T) {
+	store := &memoryStore{
+		tuples: []Tuple{
+			tuple(t, "doc:readme", "owner", "user:ana"),
+			tuple(t, "doc:readme", "viewer", "role:staff"),
+			tuple(t, "doc:readme", "parent", "doc:folder"),
+			tuple(t, "doc:folder", "viewer", "user:caio"),
+			tuple(t, "doc:spec", "viewer", "group:eng#member"),
+			tuple(t, "group:eng", "member", "user:bia"),
+			// group:a and group:b are members of each other.
+			tuple(t, "group:a", "member", "group:b#member"),
+			tuple(t, "group:b", "member", "group:a#member"),
+			tuple(t, "group:b", "member", "user:duda"),
+			tuple(t, "loop:x", "a", "user:ana"),
+		},
+		roles: map[string][]string{"rui": {"staff"}},
+	}
+	checker := &Checker{Store: store, Namespaces: []Namespace{documents, cyclic}}
+
+	tests := []struct {
+		name     string
+		object   string
+		relation string
+		subject  string
+		want     bool
+	}{
+		{"direct", "doc:readme", "owner", "user:ana", true},
+		{"computed userset", "doc:readme", "viewer", "user:ana", true},
+		{"role of the user", "doc:readme", "viewer", "user:rui", true},
+		{"role itself", "doc:readme", "viewer", "role:staff", true},
+		{"tuple to userset", "doc:readme", "viewer", "user:caio", true},
+		{"userset subject", "doc:spec", "viewer", "user:bia", true},
+		{"no relation", "doc:readme", "editor", "user:caio", false},
+		{"unknown object", "doc:other", "viewer", "user:ana", false},
+		{"cyclic tuples reaching the user", "group:a", "member", "user:duda", true},
+		{"cyclic tuples", "group:a", "member", "user:ana", false},
+		{"cyclic rewrites reaching the user", "loop:x", "b", "user:ana", true},
+		{"cyclic rewrites", "loop:x", "b", "user:bia", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object, _ := ParseSubject(tt.object)
+			subject, _ := ParseSubject(tt.subject)
+			got, err := checker.Check(context.Background(), object.Type, object.ID, tt.relation, subject)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMaxDepth(t *testing.T) {
+	store := &memoryStore{tuples: []Tuple{
+		tuple(t, "group:1", "member", "group:2#member"),
+		tuple(t, "group:2", "member", "group:3#member"),
+		tuple(t, "group:3", "member", "group:4#member"),
+		tuple(t, "group:4", "member", "user:ana"),
+	}}
+	subject := Subject{Type: SubjectUser, ID: "ana"}
+
+	deep := &Checker{Store: store}
+	if ok, err := deep.Check(context.Background(), "group", "1", "member", subject); err != nil || !ok {
+		t.Fatalf("Check() = %v, %v, want true", ok, err)
+	}
+	shallow := &Checker{Store: store, MaxDepth: 2}
+	if _, err := shallow.Check(context.Background(), "group", "1", "member", subject); err == nil || !strings.Contains(err.Error(), "max depth") {
+		t.Fatalf("Check() error = %v, want max depth", err)
+	}
+}
+
+func TestExpandCycle(t *testing.T) {
+	store := &memoryStore{tuples: []Tuple{
+		tuple(t, "group:a", "member", "group:b#member"),
+		tuple(t, "group:b", "member", "group:a#member"),
+	}}
+	checker := &Checker{Store: store}
+	root, err := checker.Expand(context.Background(), "group", "a", "member")
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	// union a > this > union b > this > cycle a
+	leaf := root.Children[0].Children[0].Children[0].Children[0]
+	if leaf.Operation != "cycle" || leaf.Object != "group:a" {
+		t.Errorf("Expand() leaf = %+v, want a cycle on group:a", leaf)
+	}
+}
@@ -0,0 +1,93 @@
+package rebac
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SubjectUser = "user"
+	SubjectRole = "role"
+)
+
+// Subject is either a user ("user:<id>"), every member of a role
+// ("role:<id>"), a userset ("<namespace>:<id>#<relation>") or a plain
+// object ("<namespace>:<id>") followed by tuple-to-userset rewrites.
+type Subject struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Relation string `json:"relation,omitempty"`
+}
+
+func (s Subject) String() string {
+	if s.Relation != "" {
+		return fmt.Sprintf("%s:%s#%s", s.Type, s.ID, s.Relation)
+	}
+	return fmt.Sprintf("%s:%s", s.Type, s.ID)
+}
+
+func (s Subject) IsUserset() bool {
+	return s.Relation != ""
+}
+
+// Tuple states that Subject has Relation on the object Namespace:ObjectID.
+type Tuple struct {
+	Namespace string  `json:"namespace"`
+	ObjectID  string  `json:"object_id"`
+	Relation  string  `json:"relation"`
+	Subject   Subject `json:"subject"`
+}
+
+func (t Tuple) String() string {
+	return fmt.Sprintf("%s:%s#%s@%s", t.Namespace, t.ObjectID, t.Relation, t.Subject)
+}
+
+// Namespace declares the relations of an object type. A relation without a
+// rewrite is satisfied only by tuples stored for it.
+type Namespace struct {
+	Name      string
+	Relations map[string]Rewrite
+}
+
+// Rewrite is the union of the usersets that grant a relation.
+type Rewrite struct {
+	Union []Userset
+}
+
+// Userset is one branch of a rewrite. Exactly one field should be set:
+// This for the stored tuples of the relation, ComputedUserset for another
+// relation on the same object, or TupleToUserset to follow a relation to
+// other objects and check a relation there.
+type Userset struct {
+	This            bool
+	ComputedUserset string
+	TupleToUserset  *TupleToUserset
+}
+
+type TupleToUserset struct {
+	Tupleset        string
+	ComputedUserset string
+}
+
+func ParseSubject(s string) (Subject, error) {
+	typ, rest, ok := strings.Cut(s, ":")
+	if !ok || typ == "" || rest == "" {
+		return Subject{}, fmt.Errorf("invalid subject %q", s)
+	}
+	id, relation, _ := strings.Cut(rest, "#")
+	return Subject{Type: typ, ID: id, Relation: relation}, nil
+}
+
+func (n Namespace) rewrite(relation string) Rewrite {
+	if r, ok := n.Relations[relation]; ok && len(r.Union) > 0 {
+		return r
+	}
+	return Rewrite{Union: []Userset{{This: true}}}
+}
+
+func (n Namespace) Validate(relation string) error {
+	if _, ok := n.Relations[relation]; !ok {
+		return fmt.Errorf("relation %q is not defined in namespace %q", relation, n.Name)
+	}
+	return nil
+}
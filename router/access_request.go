@@ -0,0 +1,68 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) ListAccessRequest(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ListAccessRequests{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.ListAccessRequestsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/", h...)
+}
+
+func (r *AppRouter) CreateAccessRequest(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.CreateAccessRequest{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.CreateAccessRequestHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/", h...)
+}
+
+func (r *AppRouter) ApproveAccessRequest(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ReviewAccessRequest{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.ApproveAccessRequestHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/:id/approve", h...)
+}
+
+func (r *AppRouter) DenyAccessRequest(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ReviewAccessRequest{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.DenyAccessRequestHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/:id/deny", h...)
+}
@@ -3,15 +3,21 @@ package router
 import (
 	"crypto/rsa"
 	"os"
+	"time"
 
 	"github.com/go-gorote/auth/controller"
 	"github.com/go-gorote/auth/goroteadmin"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/policy"
+	"github.com/go-gorote/auth/rebac"
+	"github.com/go-gorote/auth/secret"
 	"github.com/go-gorote/gorote"
 	"github.com/go-gorote/gorote/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"github.com/gofiber/swagger"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type AppRouter struct {
@@ -19,6 +25,34 @@ type AppRouter struct {
 	PublicKey  *rsa.PublicKey
 	Storage    storage.StorageProvider
 	Controller controller.Controller
+	Authorizer policy.Authorizer
+	Relations  *rebac.Checker
+	// Accounts rejects the tokens of users whose account isn't active.
+	Accounts secret.AccountChecker
+}
+
+// ProtectedRoute is secret.ProtectedRoute, also rejecting the tokens of
+// users whose account isn't active anymore.
+func (r *AppRouter) ProtectedRoute(p ...permission.PermissionCode) func(jwt.Claims) *fiber.Error {
+	return secret.CheckAccount(r.Accounts, secret.ProtectedRoute(p...))
+}
+
+// ProtectedPasswordChange is secret.ProtectedPasswordChange, also rejecting
+// the tokens of users whose account isn't active anymore.
+func (r *AppRouter) ProtectedPasswordChange() func(jwt.Claims) *fiber.Error {
+	return secret.CheckAccount(r.Accounts, secret.ProtectedPasswordChange())
+}
+
+// Authorize returns a middleware that evaluates the stored policies for the
+// action on the resource. Use it after the JWT middleware.
+func (r *AppRouter) Authorize(action, resource string, resolve policy.ResourceResolver) fiber.Handler {
+	return policy.Middleware(r.Authorizer, action, resource, resolve)
+}
+
+// RequireRecentAuth returns a middleware rejecting tokens whose user
+// authenticated more than maxAge ago. Use it after the JWT middleware.
+func (r *AppRouter) RequireRecentAuth(maxAge time.Duration) fiber.Handler {
+	return secret.RequireRecentAuth(maxAge)
 }
 
 func (r *AppRouter) RegisterBaseRouter(router fiber.Router, docSwagger bool) {
@@ -37,12 +71,24 @@ func (r *AppRouter) RegisterBaseRouter(router fiber.Router, docSwagger bool) {
 	r.Login(router.Group("/auth", gorote.Limited(60)))
 	r.Logout(router.Group("/auth"))
 	r.Refresh(router.Group("/auth", gorote.Limited(60)))
+	r.Reauthenticate(router.Group("/auth", gorote.Limited(60)))
+	r.ChangeExpiredPassword(router.Group("/auth", gorote.Limited(60)))
+	r.Me(router.Group("/auth"))
+	r.UpdateMe(router.Group("/auth"))
+	r.ConfirmEmail(router.Group("/auth", gorote.Limited(60)))
+	r.MyPermissions(router.Group("/auth"))
+	r.AcceptInvitation(router.Group("/auth", gorote.Limited(60)))
+	r.Register(router.Group("/auth", gorote.Limited(60)))
+	r.VerifyRegistration(router.Group("/auth", gorote.Limited(60)))
 	// Route Group users
 	r.ListUser(router.Group("/users"))
 	r.RecieveUser(router.Group("/users"))
 	r.CreateUser(router.Group("/users"))
+	r.ImportUsers(router.Group("/users"))
 	r.UpdateUser(router.Group("/users"))
+	r.ChangeUserStatus(router.Group("/users"))
 	r.ChangePassword(router.Group("/users"))
+	r.EffectivePermissions(router.Group("/users"))
 	// Route Group roles
 	r.ListRole(router.Group("/roles"))
 	r.CreateRole(router.Group("/roles"))
@@ -51,10 +97,43 @@ func (r *AppRouter) RegisterBaseRouter(router fiber.Router, docSwagger bool) {
 	r.ListPermission(router.Group("/permissions"))
 	r.CreatePermission(router.Group("/permissions"))
 	r.UpdatePermission(router.Group("/permissions"))
+	// Route Group groups
+	r.ListGroup(router.Group("/groups"))
+	r.CreateGroup(router.Group("/groups"))
+	r.UpdateGroup(router.Group("/groups"))
+	r.AddGroupMembers(router.Group("/groups"))
+	r.RemoveGroupMembers(router.Group("/groups"))
+	r.DeleteGroup(router.Group("/groups"))
 	// Route Group tenant
 	r.ListTenant(router.Group("/tenants"))
 	r.CreateTenant(router.Group("/tenants"))
 	r.UpdateTenant(router.Group("/tenants"))
+	// Route Group policies
+	r.ListPolicy(router.Group("/policies"))
+	r.CreatePolicy(router.Group("/policies"))
+	r.UpdatePolicy(router.Group("/policies"))
+	// Route Group relations
+	r.ListRelation(router.Group("/relations"))
+	r.WriteRelation(router.Group("/relations"))
+	r.DeleteRelation(router.Group("/relations"))
+	r.CheckRelation(router.Group("/relations"))
+	r.ExpandRelation(router.Group("/relations"))
+	r.ListObjects(router.Group("/relations"))
+	// Route Group authz
+	r.CheckAccess(router.Group("/authz"))
+	r.CheckAccessBatch(router.Group("/authz"))
+	// Route Group access requests
+	r.ListAccessRequest(router.Group("/access-requests"))
+	r.CreateAccessRequest(router.Group("/access-requests"))
+	r.ApproveAccessRequest(router.Group("/access-requests"))
+	r.DenyAccessRequest(router.Group("/access-requests"))
+	// Route Group invitations
+	r.ListInvitation(router.Group("/invitations"))
+	r.CreateInvitation(router.Group("/invitations"))
+	r.ResendInvitation(router.Group("/invitations"))
+	r.RevokeInvitation(router.Group("/invitations"))
+	// Route Group separation of duties
+	r.SodViolations(router.Group("/sod"))
 }
 
 func (r *AppRouter) registerStaticRouter(router fiber.Router) {
@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"github.com/go-gorote/gorote"
 	"github.com/gofiber/fiber/v2"
 )
@@ -44,3 +45,43 @@ func (r *AppRouter) Refresh(router fiber.Router, handlers ...fiber.Handler) {
 
 	router.Post("/refresh", h...)
 }
+
+func (r *AppRouter) Reauthenticate(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.Reauthenticate{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.ReauthenticateHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+	router.Post("/reauthenticate", h...)
+}
+
+func (r *AppRouter) Register(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.Register{}),
+			r.Controller.RegisterHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+	router.Post("/register", h...)
+}
+
+func (r *AppRouter) VerifyRegistration(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.VerifyRegistration{}),
+			r.Controller.VerifyRegistrationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+	router.Post("/register/verify", h...)
+}
@@ -0,0 +1,35 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) CheckAccess(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.AuthzCheck{}),
+			r.Controller.CheckAccessHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/check", h...)
+}
+
+func (r *AppRouter) CheckAccessBatch(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.AuthzCheckBatch{}),
+			r.Controller.CheckAccessBatchHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/check/batch", h...)
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) ListGroup(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.Paginate{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewGroup,
+				permission.PermissionUpdateGroup,
+			)),
+			r.Controller.ListGroupsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/", h...)
+}
+
+func (r *AppRouter) CreateGroup(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.CreateGroup{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreateGroup,
+			)),
+			r.Controller.CreateGroupHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/", h...)
+}
+
+func (r *AppRouter) UpdateGroup(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.UpdateGroup{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionUpdateGroup,
+			)),
+			r.Controller.UpdateGroupHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Put("/:id", h...)
+}
+
+func (r *AppRouter) AddGroupMembers(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.GroupMembers{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionUpdateGroup,
+			)),
+			r.Controller.AddGroupMembersHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/:id/members", h...)
+}
+
+func (r *AppRouter) RemoveGroupMembers(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.GroupMembers{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionUpdateGroup,
+			)),
+			r.Controller.RemoveGroupMembersHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Delete("/:id/members", h...)
+}
+
+func (r *AppRouter) DeleteGroup(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.RecieveGroup{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionDeleteGroup,
+			)),
+			r.Controller.DeleteGroupHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Delete("/:id", h...)
+}
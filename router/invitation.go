@@ -0,0 +1,91 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) ListInvitation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ListInvitations{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreateUser,
+			)),
+			r.Controller.ListInvitationsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/", h...)
+}
+
+func (r *AppRouter) CreateInvitation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.CreateInvitation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreateUser,
+			)),
+			r.Controller.CreateInvitationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/", h...)
+}
+
+func (r *AppRouter) ResendInvitation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.RecieveInvitation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreateUser,
+			)),
+			r.Controller.ResendInvitationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/:id/resend", h...)
+}
+
+func (r *AppRouter) RevokeInvitation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.RecieveInvitation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreateUser,
+			)),
+			r.Controller.RevokeInvitationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/:id/revoke", h...)
+}
+
+func (r *AppRouter) AcceptInvitation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.AcceptInvitation{}),
+			r.Controller.AcceptInvitationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/invitations/accept", h...)
+}
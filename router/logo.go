@@ -13,7 +13,7 @@ func (r *AppRouter) UpdateLogo(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.UpdateLogo{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionAdmin,
 			)),
 			r.Controller.UpdateLogoHandler,
@@ -12,7 +12,7 @@ func (r *AppRouter) ChangePassword(router fiber.Router, handlers ...fiber.Handle
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.ChangePassword{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute()),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedPasswordChange()),
 			r.Controller.ChangePasswordHandler,
 		)
 	} else {
@@ -21,3 +21,17 @@ func (r *AppRouter) ChangePassword(router fiber.Router, handlers ...fiber.Handle
 
 	router.Put("/password/:id", h...)
 }
+
+func (r *AppRouter) ChangeExpiredPassword(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ChangeExpiredPassword{}),
+			r.Controller.ChangeExpiredPasswordHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/password/expired", h...)
+}
@@ -13,7 +13,7 @@ func (r *AppRouter) ListPermission(router fiber.Router, handlers ...fiber.Handle
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.Paginate{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionViewPermission,
 				permission.PermissionCreateRole,
 				permission.PermissionUpdatePermission,
@@ -32,7 +32,7 @@ func (r *AppRouter) CreatePermission(router fiber.Router, handlers ...fiber.Hand
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.CreatePermission{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionCreatePermission,
 			)),
 			r.Controller.CreatePermissiontHandler,
@@ -49,7 +49,7 @@ func (r *AppRouter) UpdatePermission(router fiber.Router, handlers ...fiber.Hand
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.UpdatePermission{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionUpdatePermission,
 			)),
 			r.Controller.UpdatePermissiontHandler,
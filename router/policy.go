@@ -0,0 +1,61 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) ListPolicy(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.Paginate{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewPolicy,
+				permission.PermissionUpdatePolicy,
+			)),
+			r.Controller.ListPoliciesHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/", h...)
+}
+
+func (r *AppRouter) CreatePolicy(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.CreatePolicy{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreatePolicy,
+			)),
+			r.Controller.CreatePolicyHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/", h...)
+}
+
+func (r *AppRouter) UpdatePolicy(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.UpdatePolicy{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionUpdatePolicy,
+			)),
+			r.Controller.UpdatePolicyHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Put("/:id", h...)
+}
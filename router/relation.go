@@ -0,0 +1,112 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) ListRelation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ListRelations{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewRelation,
+				permission.PermissionUpdateRelation,
+			)),
+			r.Controller.ListRelationsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/", h...)
+}
+
+func (r *AppRouter) WriteRelation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.WriteRelation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionUpdateRelation,
+			)),
+			r.Controller.WriteRelationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/", h...)
+}
+
+func (r *AppRouter) DeleteRelation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.WriteRelation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionUpdateRelation,
+			)),
+			r.Controller.DeleteRelationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Delete("/", h...)
+}
+
+func (r *AppRouter) CheckRelation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.CheckRelation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewRelation,
+			)),
+			r.Controller.CheckRelationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/check", h...)
+}
+
+func (r *AppRouter) ExpandRelation(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ExpandRelation{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewRelation,
+			)),
+			r.Controller.ExpandRelationHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/expand", h...)
+}
+
+func (r *AppRouter) ListObjects(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ListObjects{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewRelation,
+			)),
+			r.Controller.ListObjectsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/list-objects", h...)
+}
@@ -13,7 +13,7 @@ func (r *AppRouter) ListRole(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.Paginate{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionViewRole,
 				permission.PermissionCreateUser,
 				permission.PermissionUpdateUser,
@@ -32,7 +32,7 @@ func (r *AppRouter) CreateRole(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.CreateRole{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionCreateRole,
 			)),
 			r.Controller.CreateRoleHandler,
@@ -49,7 +49,7 @@ func (r *AppRouter) UpdateRole(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.UpdateRole{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionUpdateRole,
 			)),
 			r.Controller.UpdateRoleHandler,
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *AppRouter) SodViolations(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionViewUser,
+				permission.PermissionViewRole,
+			)),
+			r.Controller.SodViolationsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/violations", h...)
+}
@@ -13,7 +13,7 @@ func (r *AppRouter) ListTenant(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.Paginate{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionViewTenant,
 				permission.PermissionCreateUser,
 				permission.PermissionUpdateUser,
@@ -31,7 +31,7 @@ func (r *AppRouter) CreateTenant(router fiber.Router, handlers ...fiber.Handler)
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.CreateTenant{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionCreateTenant,
 			)),
 			r.Controller.CreateTenantHandler,
@@ -48,7 +48,7 @@ func (r *AppRouter) UpdateTenant(router fiber.Router, handlers ...fiber.Handler)
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.UpdateTenant{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionUpdateTenant,
 			)),
 			r.Controller.UpdateTenantHandler,
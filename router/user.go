@@ -13,7 +13,7 @@ func (r *AppRouter) RecieveUser(router fiber.Router, handlers ...fiber.Handler)
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.RecieveUser{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute()),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
 			r.Controller.RecieveUserHandler,
 		)
 	} else {
@@ -28,7 +28,7 @@ func (r *AppRouter) ListUser(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.Paginate{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionViewUser,
 				permission.PermissionUpdateUser,
 			)),
@@ -46,7 +46,7 @@ func (r *AppRouter) CreateUser(router fiber.Router, handlers ...fiber.Handler) {
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.CreateUser{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute(
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
 				permission.PermissionCreateUser,
 			)),
 			r.Controller.CreateUserHandler,
@@ -58,12 +58,29 @@ func (r *AppRouter) CreateUser(router fiber.Router, handlers ...fiber.Handler) {
 	router.Post("/", h...)
 }
 
+func (r *AppRouter) ImportUsers(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ImportUsers{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(
+				permission.PermissionCreateUser,
+			)),
+			r.Controller.ImportUsersHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/import", h...)
+}
+
 func (r *AppRouter) UpdateUser(router fiber.Router, handlers ...fiber.Handler) {
 	var h []fiber.Handler
 	if len(handlers) == 0 {
 		h = append(h,
 			gorote.ValidationMiddleware(&schema.UpdateUser{}),
-			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, secret.ProtectedRoute()),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
 			r.Controller.UpdateUserHandler,
 		)
 	} else {
@@ -72,3 +89,90 @@ func (r *AppRouter) UpdateUser(router fiber.Router, handlers ...fiber.Handler) {
 
 	router.Put("/:id", h...)
 }
+
+func (r *AppRouter) ChangeUserStatus(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ChangeUserStatus{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute(permission.PermissionUpdateUser)),
+			r.Controller.ChangeUserStatusHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/:id/status", h...)
+}
+
+func (r *AppRouter) EffectivePermissions(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.RecieveUser{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.EffectivePermissionsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/:id/effective-permissions", h...)
+}
+
+func (r *AppRouter) MyPermissions(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.MyPermissionsHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/me/permissions", h...)
+}
+
+func (r *AppRouter) Me(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.MeHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Get("/me", h...)
+}
+
+func (r *AppRouter) UpdateMe(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.UpdateMe{}),
+			gorote.JWTProtectedRSA(&secret.JwtClaims{}, r.PublicKey, r.ProtectedRoute()),
+			r.Controller.UpdateMeHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Patch("/me", h...)
+}
+
+func (r *AppRouter) ConfirmEmail(router fiber.Router, handlers ...fiber.Handler) {
+	var h []fiber.Handler
+	if len(handlers) == 0 {
+		h = append(h,
+			gorote.ValidationMiddleware(&schema.ConfirmEmail{}),
+			r.Controller.ConfirmEmailHandler,
+		)
+	} else {
+		h = append(h, handlers...)
+	}
+
+	router.Post("/me/email/confirm", h...)
+}
@@ -2,10 +2,17 @@ package schema
 
 import (
 	"mime/multipart"
+	"time"
+
+	"github.com/go-gorote/auth/policy"
 )
 
+// Login authenticates a user by the email, username or E.164 phone in
+// Identifier, among the kinds the configuration allows.
 type Login struct {
-	Email    string `json:"email" validate:"required,email"`
+	Identifier string `json:"identifier" validate:"required_without=Email,max=254"`
+	// Email is the identifier of clients predating Identifier.
+	Email    string `json:"email" validate:"omitempty,email"`
 	Password string `json:"password" validate:"required"`
 }
 
@@ -35,14 +42,16 @@ type UpdateTenant struct {
 }
 
 type CreateRole struct {
-	Name        string   `json:"name" validate:"required,min=3,max=100"`
-	Description string   `json:"description" validate:"omitempty"`
-	Permissions []string `json:"permissions" validate:"omitempty"`
+	Name              string   `json:"name" validate:"required,min=3,max=100"`
+	Description       string   `json:"description" validate:"omitempty"`
+	Permissions       []string `json:"permissions" validate:"omitempty"`
+	DeniedPermissions []string `json:"denied_permissions" validate:"omitempty"`
 }
 
 type CreatePermission struct {
 	Code        string   `json:"code" validate:"required,min=3,max=100"`
 	Description string   `json:"description" validate:"omitempty"`
+	Group       string   `json:"group" validate:"omitempty,max=50"`
 	Roles       []string `json:"roles" validate:"omitempty"`
 	Active      bool     `json:"active" validate:"required"`
 }
@@ -51,6 +60,7 @@ type UpdatePermission struct {
 	ID          string   `param:"id" validate:"required"`
 	Code        string   `json:"code" validate:"required,min=3,max=100"`
 	Description string   `json:"description" validate:"omitempty"`
+	Group       string   `json:"group" validate:"omitempty,max=50"`
 	Roles       []string `json:"roles" validate:"omitempty"`
 	Active      bool     `json:"active" validate:"omitempty"`
 }
@@ -67,7 +77,63 @@ type CreateUser struct {
 	Phone1      string                `json:"phone1" validate:"required,e164"`
 	Phone2      string                `json:"phone2" validate:"omitempty,e164"`
 	Avatar      *multipart.FileHeader `json:"avatar" validate:"omitempty"`
-	Password    string                `json:"password" validate:"required,min=8,max=72"`
+	Password    string                `json:"password" validate:"required_without=GeneratePassword,excluded_with=GeneratePassword,max=72"`
+	// GeneratePassword sets a random temporary password instead of
+	// Password. The user must change it at their first login.
+	GeneratePassword bool `json:"generate_password" validate:"omitempty"`
+	// MustChangePassword makes the user change Password at their first
+	// login.
+	MustChangePassword bool `json:"must_change_password" validate:"omitempty"`
+
+	RoleAssignments   []Assignment `json:"role_assignments" validate:"omitempty,dive"`
+	TenantAssignments []Assignment `json:"tenant_assignments" validate:"omitempty,dive"`
+}
+
+// Assignment grants a role or tenant for a window. Either bound may be left
+// open.
+type Assignment struct {
+	ID        string     `json:"id" validate:"required,uuid"`
+	StartsAt  *time.Time `json:"starts_at" validate:"omitempty"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty"`
+}
+
+// ImportUsers creates users migrated from another system with the password
+// hashes it stored. See passwd.Legacy for the accepted formats.
+type ImportUsers struct {
+	Users []ImportUser `json:"users" validate:"required,min=1,max=1000,dive"`
+}
+
+type ImportUser struct {
+	Email             string     `json:"email" validate:"required,email"`
+	Username          string     `json:"username" validate:"required,min=3,max=50,regexp=^[a-zA-Z0-9._]+$"`
+	FirstName         string     `json:"first_name" validate:"required,min=1,max=50"`
+	LastName          string     `json:"last_name" validate:"omitempty,max=50"`
+	Active            bool       `json:"active" validate:"omitempty"`
+	Roles             []string   `json:"roles" validate:"omitempty"`
+	Tenants           []string   `json:"tenants" validate:"omitempty"`
+	Phone1            string     `json:"phone1" validate:"required,e164"`
+	Phone2            string     `json:"phone2" validate:"omitempty,e164"`
+	PasswordHash      string     `json:"password_hash" validate:"required,max=255"`
+	PasswordChangedAt *time.Time `json:"password_changed_at" validate:"omitempty"`
+}
+
+// UpdateMe holds the fields users may change on their own profile. Fields
+// left out are kept. A new email only takes effect once confirmed.
+type UpdateMe struct {
+	FirstName *string               `json:"first_name" validate:"omitempty,min=1,max=50"`
+	LastName  *string               `json:"last_name" validate:"omitempty,max=50"`
+	Phone1    *string               `json:"phone1" validate:"omitempty,e164"`
+	Phone2    *string               `json:"phone2" validate:"omitempty,e164"`
+	Locale    *string               `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	Email     *string               `json:"email" validate:"omitempty,email"`
+	Avatar    *multipart.FileHeader `json:"avatar" validate:"omitempty"`
+	// CurrentPassword is required to change the email without a recent
+	// authentication.
+	CurrentPassword string `json:"current_password" validate:"omitempty,max=72"`
+}
+
+type ConfirmEmail struct {
+	Token string `json:"token" validate:"required"`
 }
 
 type RecieveUser struct {
@@ -77,6 +143,21 @@ type RecieveUser struct {
 type ChangePassword struct {
 	ID       string `param:"id" validate:"required"`
 	Password string `json:"password" validate:"required,min=8,max=72"`
+	// CurrentPassword is required when users change their own password
+	// without a recent authentication.
+	CurrentPassword string `json:"current_password" validate:"omitempty,max=72"`
+}
+
+type ChangeExpiredPassword struct {
+	Identifier  string `json:"identifier" validate:"required_without=Email,max=254"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	Password    string `json:"password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type Reauthenticate struct {
+	Password string `json:"password" validate:"required_without=Code,omitempty,max=72"`
+	Code     string `json:"code" validate:"required_without=Password,omitempty,max=20"`
 }
 
 type UpdateUser struct {
@@ -91,17 +172,197 @@ type UpdateUser struct {
 	Tenants     []string `json:"tenants" validate:"omitempty"`
 	Phone1      string   `json:"phone1" validate:"required,e164"`
 	Phone2      string   `json:"phone2" validate:"omitempty,e164"`
+
+	// RoleAssignments and TenantAssignments set the window of the roles
+	// and tenants they mention. The others keep their current window.
+	RoleAssignments   []Assignment `json:"role_assignments" validate:"omitempty,dive"`
+	TenantAssignments []Assignment `json:"tenant_assignments" validate:"omitempty,dive"`
 }
 
 type UpdateRole struct {
-	ID          string   `param:"id" validate:"required"`
-	Name        string   `json:"name" validate:"omitempty,min=1,max=50"`
-	Description string   `json:"description" validate:"omitempty,max=50"`
+	ID                string   `param:"id" validate:"required"`
+	Name              string   `json:"name" validate:"omitempty,min=1,max=50"`
+	Description       string   `json:"description" validate:"omitempty,max=50"`
+	Permissions       []string `json:"permissions" validate:"omitempty"`
+	DeniedPermissions []string `json:"denied_permissions" validate:"omitempty"`
+	Active            bool     `json:"active" validate:"omitempty"`
+}
+
+type CreatePolicy struct {
+	Name        string             `json:"name" validate:"required,min=3,max=100,regexp=^[a-zA-Z0-9_]+$"`
+	Description string             `json:"description" validate:"omitempty"`
+	Effect      policy.Effect      `json:"effect" validate:"required,oneof=allow deny"`
+	Actions     []string           `json:"actions" validate:"required,min=1"`
+	Resource    string             `json:"resource" validate:"required,max=100"`
+	Conditions  []policy.Condition `json:"conditions" validate:"omitempty"`
+	Active      bool               `json:"active" validate:"omitempty"`
+}
+
+type UpdatePolicy struct {
+	ID          string             `param:"id" validate:"required"`
+	Name        string             `json:"name" validate:"required,min=3,max=100,regexp=^[a-zA-Z0-9_]+$"`
+	Description string             `json:"description" validate:"omitempty"`
+	Effect      policy.Effect      `json:"effect" validate:"required,oneof=allow deny"`
+	Actions     []string           `json:"actions" validate:"required,min=1"`
+	Resource    string             `json:"resource" validate:"required,max=100"`
+	Conditions  []policy.Condition `json:"conditions" validate:"omitempty"`
+	Active      bool               `json:"active" validate:"omitempty"`
+}
+
+type ListRelations struct {
+	Namespace string `query:"namespace" validate:"required"`
+	ObjectID  string `query:"object_id" validate:"omitempty"`
+	Relation  string `query:"relation" validate:"omitempty"`
+	Page      uint   `query:"page" validate:"required,min=1"`
+	Limit     uint   `query:"limit" validate:"required,min=1"`
+}
+
+type WriteRelation struct {
+	Namespace       string `json:"namespace" validate:"required,max=100"`
+	ObjectID        string `json:"object_id" validate:"required,max=100"`
+	Relation        string `json:"relation" validate:"required,max=100"`
+	SubjectType     string `json:"subject_type" validate:"required,max=100"`
+	SubjectID       string `json:"subject_id" validate:"required,max=100"`
+	SubjectRelation string `json:"subject_relation" validate:"omitempty,max=100"`
+}
+
+type CheckRelation struct {
+	Namespace   string `json:"namespace" validate:"required"`
+	ObjectID    string `json:"object_id" validate:"required"`
+	Relation    string `json:"relation" validate:"required"`
+	SubjectType string `json:"subject_type" validate:"required,oneof=user role"`
+	SubjectID   string `json:"subject_id" validate:"required"`
+}
+
+type ExpandRelation struct {
+	Namespace string `json:"namespace" validate:"required"`
+	ObjectID  string `json:"object_id" validate:"required"`
+	Relation  string `json:"relation" validate:"required"`
+}
+
+type ListObjects struct {
+	Namespace   string `json:"namespace" validate:"required"`
+	Relation    string `json:"relation" validate:"required"`
+	SubjectType string `json:"subject_type" validate:"required,oneof=user role"`
+	SubjectID   string `json:"subject_id" validate:"required"`
+}
+
+type AuthzCheck struct {
+	SubjectID   string   `json:"subject_id" validate:"omitempty"`
+	Token       string   `json:"token" validate:"omitempty"`
 	Permissions []string `json:"permissions" validate:"omitempty"`
-	Active      bool     `json:"active" validate:"omitempty"`
+	Tenant      string   `json:"tenant" validate:"omitempty"`
+}
+
+type AuthzCheckBatch struct {
+	Checks []AuthzCheck `json:"checks" validate:"required,min=1,max=100"`
 }
 
 type Paginate struct {
 	Page  uint `query:"page" validate:"required,min=1"`
 	Limit uint `query:"limit" validate:"required,min=1"`
 }
+
+type CreateAccessRequest struct {
+	RoleID        string `json:"role_id" validate:"omitempty,uuid"`
+	TenantID      string `json:"tenant_id" validate:"omitempty,uuid"`
+	Justification string `json:"justification" validate:"required,min=10,max=500"`
+	// Duration is a Go duration such as "8h"; empty requests a permanent grant.
+	Duration string `json:"duration" validate:"omitempty"`
+}
+
+type ListAccessRequests struct {
+	Page   uint   `query:"page" validate:"required,min=1"`
+	Limit  uint   `query:"limit" validate:"required,min=1"`
+	Status string `query:"status" validate:"omitempty,oneof=pending approved denied"`
+}
+
+type ListInvitations struct {
+	Page   uint   `query:"page" validate:"required,min=1"`
+	Limit  uint   `query:"limit" validate:"required,min=1"`
+	Status string `query:"status" validate:"omitempty,oneof=pending accepted revoked expired"`
+}
+
+// CreateInvitation invites the owner of Email with the roles and tenants.
+// The link expires at ExpiresAt, or after the configured InvitationTTL.
+type CreateInvitation struct {
+	Email     string     `json:"email" validate:"required,email"`
+	Roles     []string   `json:"roles" validate:"omitempty"`
+	Tenants   []string   `json:"tenants" validate:"omitempty"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty"`
+}
+
+type RecieveInvitation struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+// AcceptInvitation creates the invited user with the profile and password
+// they chose. The email comes from the invitation.
+type AcceptInvitation struct {
+	Token     string `json:"token" validate:"required"`
+	Username  string `json:"username" validate:"required,min=3,max=50,regexp=^[a-zA-Z0-9._]+$"`
+	FirstName string `json:"first_name" validate:"required,min=1,max=50"`
+	LastName  string `json:"last_name" validate:"omitempty,max=50"`
+	Phone1    string `json:"phone1" validate:"required,e164"`
+	Phone2    string `json:"phone2" validate:"omitempty,e164"`
+	Password  string `json:"password" validate:"required,max=72"`
+}
+
+// ChangeUserStatus moves a user along the account lifecycle. Until ends a
+// suspension.
+type ChangeUserStatus struct {
+	ID     string     `param:"id" validate:"required,uuid"`
+	Status string     `json:"status" validate:"required,oneof=active suspended locked deprovisioned"`
+	Reason string     `json:"reason" validate:"omitempty,max=500"`
+	Until  *time.Time `json:"until" validate:"omitempty"`
+}
+
+// Register creates an account through self-registration. Roles, tenants
+// and activation follow the configuration rather than the request.
+type Register struct {
+	Email     string `json:"email" validate:"required,email"`
+	Username  string `json:"username" validate:"required,min=3,max=50,regexp=^[a-zA-Z0-9._]+$"`
+	FirstName string `json:"first_name" validate:"required,min=1,max=50"`
+	LastName  string `json:"last_name" validate:"omitempty,max=50"`
+	Phone1    string `json:"phone1" validate:"required,e164"`
+	Phone2    string `json:"phone2" validate:"omitempty,e164"`
+	Password  string `json:"password" validate:"required,max=72"`
+	// Captcha is the response of the CAPTCHA widget, checked by the
+	// configured verifier.
+	Captcha string `json:"captcha" validate:"omitempty,max=4096"`
+}
+
+type VerifyRegistration struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ReviewAccessRequest struct {
+	ID   string `param:"id" validate:"required,uuid"`
+	Note string `json:"note" validate:"omitempty,max=500"`
+}
+
+type CreateGroup struct {
+	Name        string   `json:"name" validate:"required,min=3,max=100"`
+	Description string   `json:"description" validate:"omitempty"`
+	Roles       []string `json:"roles" validate:"omitempty"`
+	Tenants     []string `json:"tenants" validate:"omitempty"`
+	Active      bool     `json:"active" validate:"omitempty"`
+}
+
+type UpdateGroup struct {
+	ID          string   `param:"id" validate:"required"`
+	Name        string   `json:"name" validate:"required,min=3,max=100"`
+	Description string   `json:"description" validate:"omitempty"`
+	Roles       []string `json:"roles" validate:"omitempty"`
+	Tenants     []string `json:"tenants" validate:"omitempty"`
+	Active      bool     `json:"active" validate:"omitempty"`
+}
+
+type RecieveGroup struct {
+	ID string `param:"id" validate:"required"`
+}
+
+type GroupMembers struct {
+	ID    string   `param:"id" validate:"required"`
+	Users []string `json:"users" validate:"required,min=1"`
+}
@@ -0,0 +1,51 @@
+package secret
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The errors of users who may not use the module in the current status of
+// their account.
+var (
+	ErrAccountPending       = errors.New("account is pending activation")
+	ErrAccountSuspended     = errors.New("account is suspended")
+	ErrAccountLocked        = errors.New("account is locked")
+	ErrAccountDeprovisioned = errors.New("account is deprovisioned")
+)
+
+// AccountChecker returns one of the ErrAccount errors when the user may not
+// use their tokens anymore, or nil.
+type AccountChecker func(userID string) error
+
+// CheckAccount wraps the protection of a route so that the tokens it lets
+// through are rejected when the checker returns an error for their user,
+// before they expire. A nil checker lets them all through.
+func CheckAccount(checker AccountChecker, protect func(jwt.Claims) *fiber.Error) func(jwt.Claims) *fiber.Error {
+	return func(c jwt.Claims) *fiber.Error {
+		if err := protect(c); err != nil || checker == nil {
+			return err
+		}
+		if err := checker(c.(*JwtClaims).ID); err != nil {
+			return fiber.NewError(AccountStatus(err), err.Error())
+		}
+		return nil
+	}
+}
+
+// AccountStatus returns the HTTP status of an ErrAccount error: 403 for
+// pending and suspended accounts, 423 for locked ones and 410 for
+// deprovisioned ones. Other errors are a 401.
+func AccountStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrAccountPending), errors.Is(err, ErrAccountSuspended):
+		return fiber.StatusForbidden
+	case errors.Is(err, ErrAccountLocked):
+		return fiber.StatusLocked
+	case errors.Is(err, ErrAccountDeprovisioned):
+		return fiber.StatusGone
+	}
+	return fiber.StatusUnauthorized
+}
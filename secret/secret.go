@@ -1,7 +1,9 @@
 package secret
 
 import (
+	"fmt"
 	"slices"
+	"time"
 
 	"github.com/go-gorote/auth/permission"
 	"github.com/gofiber/fiber/v2"
@@ -11,55 +13,155 @@ import (
 type JwtClaims struct {
 	IsSuperUser bool     `json:"isSuperUser"`
 	Permissions []string `json:"permissions"`
+	Denied      []string `json:"denied,omitempty"`
 	Tenants     []string `json:"tenants"`
 	Type        string   `json:"type"`
+	// AuthTime is when the user last proved their identity. Tokens issued by
+	// a refresh keep the time of the login that issued the refresh token.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func ProtectedRoute(p ...permission.PermissionCode) func(jwt.Claims) *fiber.Error {
-	return func(c jwt.Claims) *fiber.Error {
-		claims := c.(*JwtClaims)
-		if claims.Type == "refresh_token" {
-			return fiber.NewError(fiber.StatusUnauthorized, "token is refresh token")
+// AuthenticatedWithin reports whether the user proved their identity less
+// than maxAge ago.
+func (c *JwtClaims) AuthenticatedWithin(maxAge time.Duration) bool {
+	return c.AuthTime != nil && time.Since(c.AuthTime.Time) <= maxAge
+}
+
+// RequireRecentAuth returns a middleware rejecting tokens whose user
+// authenticated more than maxAge ago, for routes that need step-up
+// authentication. Use it after the JWT middleware; clients recover by
+// calling /auth/reauthenticate.
+func RequireRecentAuth(maxAge time.Duration) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		claims, ok := ctx.Locals("claimsData").(*JwtClaims)
+		if !ok {
+			return fiber.NewError(fiber.StatusUnauthorized, "missing token claims")
 		}
-		if claims.IsSuperUser {
-			return nil
+		if !claims.AuthenticatedWithin(maxAge) {
+			return fiber.NewError(fiber.StatusUnauthorized, "recent authentication required")
 		}
-		if len(p) == 0 {
-			return nil
+		return ctx.Next()
+	}
+}
+
+// EmailChangeClaims are carried by the link confirming a new email address.
+// Previous binds the link to the address it replaces, so it can be used
+// only once.
+type EmailChangeClaims struct {
+	Email    string `json:"email"`
+	Previous string `json:"previous"`
+	Type     string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// InvitationClaims are carried by the link accepting an invitation. The
+// registered ID is the invitation's, and Nonce binds the link to the last
+// one sent.
+type InvitationClaims struct {
+	Email string `json:"email"`
+	Nonce string `json:"nonce"`
+	Type  string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// RegistrationClaims are carried by the link verifying the email of a
+// self-registered user. The registered ID is the user's.
+type RegistrationClaims struct {
+	Email string `json:"email"`
+	Type  string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// Decision is the outcome of evaluating claims against a route's
+// requirements. Status is the HTTP status a middleware responds with when
+// the request is not allowed.
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	Status  int    `json:"-"`
+}
+
+// HasPermission reports whether the claims grant any of the given codes.
+// A code listed in Denied is never granted, even if it is also listed in
+// Permissions.
+func (c *JwtClaims) HasPermission(p ...permission.PermissionCode) bool {
+	if c.IsSuperUser {
+		return true
+	}
+	for _, permission := range p {
+		code := string(permission)
+		if slices.Contains(c.Denied, code) {
+			continue
 		}
-		for _, permission := range p {
-			if slices.Contains(claims.Permissions, string(permission)) {
-				return nil
-			}
+		if slices.Contains(c.Permissions, code) {
+			return true
 		}
-		return fiber.NewError(fiber.StatusForbidden, "you don't have permission to access this route")
 	}
+	return false
 }
 
-func ProtectedRouteWithTenants(tenant *string, p ...permission.PermissionCode) func(jwt.Claims) *fiber.Error {
-	return func(c jwt.Claims) *fiber.Error {
-		claims := c.(*JwtClaims)
-		if claims.Type == "refresh_token" {
-			return fiber.NewError(fiber.StatusUnauthorized, "token is refresh token")
+// Authorize evaluates the claims the same way ProtectedRouteWithTenants
+// does: refresh tokens are rejected, super users are allowed, the tenant
+// must be assigned when given and any one of the codes must be granted.
+func Authorize(claims *JwtClaims, tenant *string, p ...permission.PermissionCode) Decision {
+	if claims.Type == "refresh_token" {
+		return Decision{Status: fiber.StatusUnauthorized, Reason: "token is refresh token"}
+	}
+	if claims.Type == "password_change_token" {
+		return Decision{Status: fiber.StatusUnauthorized, Reason: "password must be changed first"}
+	}
+	if claims.Type != "access_token" {
+		return Decision{Status: fiber.StatusUnauthorized, Reason: "token is not an access token"}
+	}
+	if claims.IsSuperUser {
+		return Decision{Allowed: true, Reason: "super user"}
+	}
+	if tenant != nil && !slices.Contains(claims.Tenants, *tenant) {
+		return Decision{Status: fiber.StatusForbidden, Reason: fmt.Sprintf("tenant %s is not assigned", *tenant)}
+	}
+	if len(p) == 0 {
+		return Decision{Allowed: true, Reason: "no permission required"}
+	}
+	for _, permission := range p {
+		if claims.HasPermission(permission) {
+			return Decision{Allowed: true, Reason: fmt.Sprintf("granted by permission %s", permission)}
 		}
-		if claims.IsSuperUser {
-			return nil
+	}
+	for _, permission := range p {
+		if slices.Contains(claims.Denied, string(permission)) {
+			return Decision{Status: fiber.StatusForbidden, Reason: fmt.Sprintf("permission %s is denied", permission)}
 		}
+	}
+	return Decision{Status: fiber.StatusForbidden, Reason: "missing required permission"}
+}
+
+func ProtectedRoute(p ...permission.PermissionCode) func(jwt.Claims) *fiber.Error {
+	return ProtectedRouteWithTenants(nil, p...)
+}
 
-		if tenant != nil {
-			if !slices.Contains(claims.Tenants, *tenant) {
-				return fiber.NewError(fiber.StatusForbidden, "you don't have permission to access this route")
-			}
+// ProtectedPasswordChange protects the routes changing a password, which
+// also accept the restricted token issued at the login of a user who must
+// change their password.
+func ProtectedPasswordChange() func(jwt.Claims) *fiber.Error {
+	protected := ProtectedRoute()
+	return func(c jwt.Claims) *fiber.Error {
+		if c.(*JwtClaims).Type == "password_change_token" {
+			return nil
 		}
+		return protected(c)
+	}
+}
 
-		if len(p) == 0 {
+func ProtectedRouteWithTenants(tenant *string, p ...permission.PermissionCode) func(jwt.Claims) *fiber.Error {
+	return func(c jwt.Claims) *fiber.Error {
+		claims := c.(*JwtClaims)
+		decision := Authorize(claims, tenant, p...)
+		if decision.Allowed {
 			return nil
 		}
-		for _, permission := range p {
-			if slices.Contains(claims.Permissions, string(permission)) {
-				return nil
-			}
+		if decision.Status == fiber.StatusUnauthorized {
+			return fiber.NewError(decision.Status, decision.Reason)
 		}
 		return fiber.NewError(fiber.StatusForbidden, "you don't have permission to access this route")
 	}
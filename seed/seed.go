@@ -2,28 +2,86 @@ package seed
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/go-gorote/auth/base"
 	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/passwd"
 	"github.com/go-gorote/gorote"
 	"gorm.io/gorm"
 )
 
-func saveUser(db *gorm.DB, user model.User) error {
+// Seeder creates users with the password rules of a configuration. The
+// zero value of a field takes the default auth.New uses for it.
+type Seeder struct {
+	DB       *gorm.DB
+	Policy   passwd.Policy
+	Hasher   passwd.PasswordHasher
+	Breached passwd.Corpus
+}
+
+// FromConfig returns a seeder enforcing the PasswordPolicy, PasswordHasher
+// and BreachedPasswordsFile of the config. Seeded users have no tenants,
+// so the TenantPasswordPolicies don't apply to them. Close it once done.
+func FromConfig(config base.Config) (*Seeder, error) {
+	s := &Seeder{DB: config.DB, Policy: config.PasswordPolicy, Hasher: config.PasswordHasher}
+	if config.BreachedPasswordsFile != "" {
+		breached, err := passwd.OpenCorpus(config.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+		s.Breached = breached
+	}
+	return s, nil
+}
+
+// Close closes the breached password corpus.
+func (s *Seeder) Close() error {
+	if s.Breached == nil {
+		return nil
+	}
+	return s.Breached.Close()
+}
+
+func (s *Seeder) policy() passwd.Policy {
+	if s.Policy == (passwd.Policy{}) {
+		return passwd.DefaultPolicy
+	}
+	return s.Policy
+}
+
+func (s *Seeder) hasher() passwd.PasswordHasher {
+	if s.Hasher == nil {
+		return passwd.DefaultHasher
+	}
+	return s.Hasher
+}
+
+func (s *Seeder) saveUser(user model.User) error {
+	user.Email = model.NormalizeEmail(user.Email)
+	user.Username = model.NormalizeUsername(user.Username)
 	if err := gorote.ValidateStruct(user); err != nil {
 		return fmt.Errorf("erro de validação")
 	}
 
-	if err := gorote.ValidatePassword(user.Password); err != nil {
+	if err := s.policy().Validate(user.Password, passwd.UserInfo{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Username:  user.Username,
+		Email:     user.Email,
+	}, s.Breached); err != nil {
 		return err
 	}
 
-	hashPassword, err := gorote.HashPassword(user.Password)
+	hashPassword, err := s.hasher().Hash(user.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %s", err.Error())
 	}
 	user.Password = hashPassword
+	now := time.Now()
+	user.PasswordChangedAt = &now
 
-	if err := db.Create(&user).Error; err != nil {
+	if err := s.DB.Create(&user).Error; err != nil {
 		return err
 	}
 	return nil
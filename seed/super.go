@@ -5,8 +5,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// SeedSuperUser creates a super user with the default password rules. See
+// Seeder.SeedSuperUser for the configured ones.
 func SeedSuperUser(db *gorm.DB, email, password, phone string) error {
-	if err := saveUser(db,
+	return (&Seeder{DB: db}).SeedSuperUser(email, password, phone)
+}
+
+func (s *Seeder) SeedSuperUser(email, password, phone string) error {
+	if err := s.saveUser(
 		model.User{
 			FirstName:   "Super",
 			LastName:    "User",
@@ -7,13 +7,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// SeedUsers creates test users with the default password rules. See
+// Seeder.SeedUsers for the configured ones.
 func SeedUsers(db *gorm.DB) error {
+	return (&Seeder{DB: db}).SeedUsers()
+}
+
+func (s *Seeder) SeedUsers() error {
 	data := []model.User{
 		{
 			FirstName:   "Ralds",
 			Username:    "ralds.ralds",
 			Email:       "ralds@ralds.com",
-			Password:    "Ralds1@#",
+			Password:    "Ralds1@#Kv9q",
 			IsSuperUser: true,
 			Phone1:      "+5588992200365",
 			Active:      true,
@@ -23,17 +29,18 @@ func SeedUsers(db *gorm.DB) error {
 			LastName:    "grupo",
 			Username:    "grupo.grupo",
 			Email:       "grupo@grupo.com",
-			Password:    "Grupo1@#",
+			Password:    "Grupo1@#Tz4m",
 			IsSuperUser: false,
 			Phone1:      "+5588992200365",
 			Active:      false,
+			Status:      model.UserPending,
 		},
 		{
 			FirstName:   "User",
 			LastName:    "User",
 			Username:    "user.user",
 			Email:       "user@user.com",
-			Password:    "Useruser1@#",
+			Password:    "Useruser1@#Pw7x",
 			IsSuperUser: false,
 			Phone1:      "+5588996877808",
 			Active:      true,
@@ -43,7 +50,7 @@ func SeedUsers(db *gorm.DB) error {
 			LastName:    "Sistema",
 			Username:    "sistema.sistema",
 			Email:       "sistema@sistema.com",
-			Password:    "Sistema1@#",
+			Password:    "Sistema1@#Hj2c",
 			IsSuperUser: false,
 			Phone1:      "+5588999999999",
 			Active:      true,
@@ -53,7 +60,7 @@ func SeedUsers(db *gorm.DB) error {
 			LastName:    "Gorote",
 			Username:    "gorote.gorote",
 			Email:       "gorote@gorote.com",
-			Password:    "Gorote1@#",
+			Password:    "Gorote1@#Nb5r",
 			IsSuperUser: false,
 			Phone1:      "+5588999999999",
 			Active:      true,
@@ -61,7 +68,7 @@ func SeedUsers(db *gorm.DB) error {
 	}
 
 	for i := range data {
-		if err := saveUser(db, data[i]); err != nil {
+		if err := s.saveUser(data[i]); err != nil {
 			log.Println(err)
 		}
 	}
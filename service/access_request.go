@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/notify"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	NotifyAccessRequested notify.Kind = "access_requested"
+	NotifyAccessReviewed  notify.Kind = "access_reviewed"
+)
+
+// ApproverPermission returns the code that allows reviewing access requests.
+func (s *AppService) ApproverPermission() permission.PermissionCode {
+	if s.AccessApproverPermission == "" {
+		return permission.PermissionApproveAccessRequest
+	}
+	return s.AccessApproverPermission
+}
+
+// AccessRequests lists the access requests, newest first. A non-empty userID
+// limits the list to the requests of that user.
+func (s *AppService) AccessRequests(req *schema.ListAccessRequests, userID string) ([]model.AccessRequest, error) {
+	var data []model.AccessRequest
+	query := s.DB.
+		Preload("User").
+		Preload("Role").
+		Preload("Tenant").
+		Order("created_at DESC")
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if err := query.Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to query database")
+	}
+	return data, nil
+}
+
+func (s *AppService) CreateAccessRequest(ctx context.Context, userID string, req *schema.CreateAccessRequest) (*model.AccessRequest, error) {
+	if (req.RoleID == "") == (req.TenantID == "") {
+		return nil, fmt.Errorf("request either a role or a tenant")
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id")
+	}
+	data := model.AccessRequest{
+		UserID:        uid,
+		Justification: req.Justification,
+		Status:        model.AccessRequestPending,
+	}
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid duration %q", req.Duration)
+		}
+		data.Duration = duration
+	}
+
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		pending := tx.Model(&model.AccessRequest{}).
+			Where("user_id = ? AND status = ?", uid, model.AccessRequestPending)
+		if req.RoleID != "" {
+			var role model.Role
+			if err := tx.Where("id = ? AND active = ?", req.RoleID, true).First(&role).Error; err != nil {
+				return fmt.Errorf("role not found")
+			}
+			data.RoleID, data.Role = &role.ID, &role
+			pending = pending.Where("role_id = ?", role.ID)
+		} else {
+			var tenant model.Tenant
+			if err := tx.Where("id = ? AND active = ?", req.TenantID, true).First(&tenant).Error; err != nil {
+				return fmt.Errorf("tenant not found")
+			}
+			data.TenantID, data.Tenant = &tenant.ID, &tenant
+			pending = pending.Where("tenant_id = ?", tenant.ID)
+		}
+		var count int64
+		if err := pending.Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to query database")
+		}
+		if count > 0 {
+			return fmt.Errorf("an access request for this grant is already pending")
+		}
+		if err := tx.Omit("User", "Role", "Tenant").Create(&data).Error; err != nil {
+			return fmt.Errorf("failed to create access request")
+		}
+		return tx.First(&data.User, "id = ?", uid).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	approvers, err := s.approvers()
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to fetch approvers", "error", err, "access_request_id", data.ID.String())
+	} else if len(approvers) > 0 {
+		s.notify(ctx, notify.Message{
+			Kind:    NotifyAccessRequested,
+			To:      recipients(approvers...),
+			Subject: fmt.Sprintf("%s requested access to %s", data.User.Username, accessTarget(&data)),
+			Body:    data.Justification,
+			Data:    accessRequestData(&data),
+		})
+	}
+	return &data, nil
+}
+
+// ReviewAccessRequest approves or denies a pending request. An approved
+// request is granted as an assignment expiring after the requested
+// duration; an existing assignment is only ever extended. Like any other
+// grant, reviewers may only approve roles and tenants within their access.
+func (s *AppService) ReviewAccessRequest(ctx context.Context, reviewer *secret.JwtClaims, req *schema.ReviewAccessRequest, approve bool) (*model.AccessRequest, error) {
+	rid, err := uuid.Parse(reviewer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reviewer id")
+	}
+	var data model.AccessRequest
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("User").
+			Preload("Role").
+			Preload("Tenant").
+			First(&data, "id = ?", req.ID).Error; err != nil {
+			return fmt.Errorf("access request not found")
+		}
+		if data.Status != model.AccessRequestPending {
+			return fmt.Errorf("access request was already %s", data.Status)
+		}
+		if data.UserID == rid {
+			return fmt.Errorf("you can't review your own access request")
+		}
+
+		now := time.Now()
+		data.Status = model.AccessRequestDenied
+		if approve {
+			data.Status = model.AccessRequestApproved
+			var tenants []model.Tenant
+			if data.Tenant != nil {
+				tenants = append(tenants, *data.Tenant)
+			}
+			var roles []model.Role
+			if data.Role != nil {
+				if err := tx.Preload("Permissions").Preload("Grants").Find(&roles, "id = ?", data.Role.ID).Error; err != nil {
+					return fmt.Errorf("failed to fetch role")
+				}
+			}
+			if err := guardRoles(reviewer, roles, tenants); err != nil {
+				return err
+			}
+			if data.Role != nil {
+				var user model.User
+				if err := tx.Preload("Roles").Preload("Groups.Roles").First(&user, "id = ?", data.UserID).Error; err != nil {
+					return fmt.Errorf("failed to fetch user")
+				}
+				if err := s.enforceSod(append(user.AllRoles(), *data.Role)); err != nil {
+					return err
+				}
+			}
+			if err := grantAccessRequest(tx, &data, now); err != nil {
+				return err
+			}
+		}
+		data.ReviewerID = &rid
+		data.ReviewedAt = &now
+		data.ReviewNote = req.Note
+		if err := tx.Model(&data).Updates(map[string]any{
+			"status":      data.Status,
+			"reviewer_id": data.ReviewerID,
+			"reviewed_at": data.ReviewedAt,
+			"review_note": data.ReviewNote,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update access request")
+		}
+		return nil
+	}); err != nil {
+		return nil, s.auditRejection(reviewer, "approve_access_request", "access_request", req.ID, err)
+	}
+
+	if approve {
+		s.Decisions.Purge()
+	}
+	s.notify(ctx, notify.Message{
+		Kind:    NotifyAccessReviewed,
+		To:      recipients(data.User),
+		Subject: fmt.Sprintf("Your access request to %s was %s", accessTarget(&data), data.Status),
+		Body:    data.ReviewNote,
+		Data:    accessRequestData(&data),
+	})
+	return &data, nil
+}
+
+func grantAccessRequest(tx *gorm.DB, data *model.AccessRequest, now time.Time) error {
+	var until *time.Time
+	if data.Duration > 0 {
+		t := now.Add(data.Duration)
+		until = &t
+	}
+	if data.RoleID != nil {
+		var current model.UserRole
+		result := tx.Where("user_id = ? AND role_id = ?", data.UserID, *data.RoleID).Limit(1).Find(&current)
+		if result.Error != nil {
+			return fmt.Errorf("failed to fetch role assignment")
+		}
+		active := result.RowsAffected > 0 && current.ActiveAt(now)
+		grant := model.UserRole{UserID: data.UserID, RoleID: *data.RoleID, ExpiresAt: extendExpiry(active, current.ExpiresAt, until)}
+		if err := tx.Save(&grant).Error; err != nil {
+			return fmt.Errorf("failed to grant role")
+		}
+		return nil
+	}
+	var current model.UserTenant
+	result := tx.Where("user_id = ? AND tenant_id = ?", data.UserID, *data.TenantID).Limit(1).Find(&current)
+	if result.Error != nil {
+		return fmt.Errorf("failed to fetch tenant assignment")
+	}
+	active := result.RowsAffected > 0 && current.ActiveAt(now)
+	grant := model.UserTenant{UserID: data.UserID, TenantID: *data.TenantID, ExpiresAt: extendExpiry(active, current.ExpiresAt, until)}
+	if err := tx.Save(&grant).Error; err != nil {
+		return fmt.Errorf("failed to grant tenant")
+	}
+	return nil
+}
+
+// extendExpiry returns the later of the current expiry and until, where nil
+// means permanent. An inactive assignment is replaced.
+func extendExpiry(active bool, current, until *time.Time) *time.Time {
+	if !active {
+		return until
+	}
+	if current == nil || until == nil {
+		return nil
+	}
+	if current.After(*until) {
+		return current
+	}
+	return until
+}
+
+// approvers returns the active users allowed to review access requests,
+// directly or through their groups.
+func (s *AppService) approvers() ([]model.User, error) {
+	codes := []string{string(permission.PermissionAdmin), string(s.ApproverPermission())}
+	var ids []string
+	if err := s.DB.Model(&model.User{}).
+		Joins("JOIN users_roles ON users_roles.user_id = users.id").
+		Joins("JOIN roles_permissions ON roles_permissions.role_id = users_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = roles_permissions.permission_id").
+		Where("permissions.code IN ? AND users.active = ?", codes, true).
+		Distinct().
+		Pluck("users.id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to query database")
+	}
+	var members []string
+	if err := s.DB.Model(&model.User{}).
+		Joins("JOIN groups_users ON groups_users.user_id = users.id").
+		Joins("JOIN groups_roles ON groups_roles.group_id = groups_users.group_id").
+		Joins("JOIN roles_permissions ON roles_permissions.role_id = groups_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = roles_permissions.permission_id").
+		Where("permissions.code IN ? AND users.active = ?", codes, true).
+		Distinct().
+		Pluck("users.id", &members).Error; err != nil {
+		return nil, fmt.Errorf("failed to query database")
+	}
+	var supers []string
+	if err := s.DB.Model(&model.User{}).
+		Where("is_super_user = ? AND active = ?", true, true).
+		Pluck("id", &supers).Error; err != nil {
+		return nil, fmt.Errorf("failed to query database")
+	}
+	users, err := s.Users(mergeIDs(ids, members, supers)...)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(users, func(u model.User) bool {
+		if u.IsSuperUser {
+			return false
+		}
+		allowed, _ := u.EffectivePermissions()
+		return !slices.ContainsFunc(codes, func(code string) bool {
+			return slices.Contains(allowed, code)
+		})
+	}), nil
+}
+
+// notify delivers the message through the configured notifier. Failures are
+// logged and never fail the operation that triggered them.
+func (s *AppService) notify(ctx context.Context, msg notify.Message) {
+	var notifier notify.Notifier = notify.Log{Logger: s.Logger}
+	if s.Notifier != nil {
+		notifier = s.Notifier
+	}
+	if err := notifier.Notify(ctx, msg); err != nil {
+		s.Logger.ErrorContext(ctx, "failed to send notification", "error", err, "kind", msg.Kind)
+	}
+}
+
+func recipients(users ...model.User) []notify.Recipient {
+	var to []notify.Recipient
+	for _, u := range users {
+		to = append(to, notify.Recipient{
+			UserID: u.ID.String(),
+			Name:   u.FirstName,
+			Email:  u.Email,
+			Phone:  u.Phone1,
+		})
+	}
+	return to
+}
+
+func accessTarget(a *model.AccessRequest) string {
+	if a.Role != nil {
+		return "role " + a.Role.Name
+	}
+	if a.Tenant != nil {
+		return "tenant " + a.Tenant.Name
+	}
+	return "an unknown grant"
+}
+
+func accessRequestData(a *model.AccessRequest) map[string]string {
+	data := map[string]string{
+		"access_request_id": a.ID.String(),
+		"user_id":           a.UserID.String(),
+		"status":            string(a.Status),
+	}
+	if a.Duration > 0 {
+		data["duration"] = a.Duration.String()
+	}
+	return data
+}
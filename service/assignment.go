@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/schema"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// assignmentIDs returns the permanent grants followed by the time-bound ones.
+func assignmentIDs(ids []string, assignments []schema.Assignment) []string {
+	all := append([]string{}, ids...)
+	for _, a := range assignments {
+		all = append(all, a.ID)
+	}
+	return mergeIDs(all)
+}
+
+// assignmentWindow returns the window the assignments give to id, and
+// whether they mention it at all.
+func assignmentWindow(id uuid.UUID, assignments []schema.Assignment) (startsAt, expiresAt *time.Time, ok bool) {
+	for _, a := range assignments {
+		if strings.EqualFold(a.ID, id.String()) {
+			return a.StartsAt, a.ExpiresAt, true
+		}
+	}
+	return nil, nil, false
+}
+
+func validateAssignments(assignments ...[]schema.Assignment) error {
+	for _, list := range assignments {
+		for _, a := range list {
+			if a.StartsAt != nil && a.ExpiresAt != nil && !a.ExpiresAt.After(*a.StartsAt) {
+				return fmt.Errorf("assignment %s expires before it starts", a.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// setAssignments stores the window of the roles and tenants linked to the
+// user that the assignments mention. The others keep their stored window,
+// and links the user didn't have yet are permanent.
+func setAssignments(tx *gorm.DB, user *model.User, roles, tenants []schema.Assignment) error {
+	var storedRoles []model.UserRole
+	if err := tx.Where("user_id = ?", user.ID).Find(&storedRoles).Error; err != nil {
+		return fmt.Errorf("failed to fetch role assignments")
+	}
+	user.RoleAssignments = nil
+	for _, role := range user.Roles {
+		a := model.UserRole{UserID: user.ID, RoleID: role.ID}
+		startsAt, expiresAt, ok := assignmentWindow(role.ID, roles)
+		if i := slices.IndexFunc(storedRoles, func(r model.UserRole) bool { return r.RoleID == role.ID }); i >= 0 && !ok {
+			a = storedRoles[i]
+		} else {
+			a.StartsAt, a.ExpiresAt = startsAt, expiresAt
+			if err := tx.Save(&a).Error; err != nil {
+				return fmt.Errorf("failed to update role assignments")
+			}
+		}
+		user.RoleAssignments = append(user.RoleAssignments, a)
+	}
+
+	var storedTenants []model.UserTenant
+	if err := tx.Where("user_id = ?", user.ID).Find(&storedTenants).Error; err != nil {
+		return fmt.Errorf("failed to fetch tenant assignments")
+	}
+	user.TenantAssignments = nil
+	for _, tenant := range user.Tenants {
+		a := model.UserTenant{UserID: user.ID, TenantID: tenant.ID}
+		startsAt, expiresAt, ok := assignmentWindow(tenant.ID, tenants)
+		if i := slices.IndexFunc(storedTenants, func(t model.UserTenant) bool { return t.TenantID == tenant.ID }); i >= 0 && !ok {
+			a = storedTenants[i]
+		} else {
+			a.StartsAt, a.ExpiresAt = startsAt, expiresAt
+			if err := tx.Save(&a).Error; err != nil {
+				return fmt.Errorf("failed to update tenant assignments")
+			}
+		}
+		user.TenantAssignments = append(user.TenantAssignments, a)
+	}
+	return nil
+}
+
+// SweepAssignments deletes the role and tenant assignments that have
+// expired and bumps the updated_at of their users, which invalidates the
+// refresh tokens issued before the sweep.
+func (s *AppService) SweepAssignments(ctx context.Context) (int64, error) {
+	var swept int64
+	now := time.Now()
+	if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var users []uuid.UUID
+		for _, table := range []any{&model.UserRole{}, &model.UserTenant{}} {
+			var ids []uuid.UUID
+			if err := tx.Model(table).
+				Where("expires_at <= ?", now).
+				Distinct().
+				Pluck("user_id", &ids).Error; err != nil {
+				return fmt.Errorf("failed to fetch expired assignments")
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			result := tx.Where("expires_at <= ?", now).Delete(table)
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete expired assignments")
+			}
+			swept += result.RowsAffected
+			users = append(users, ids...)
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		if err := tx.Model(&model.User{}).
+			Where("id IN ?", users).
+			Update("updated_at", now).Error; err != nil {
+			return fmt.Errorf("failed to revoke sessions")
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if swept > 0 {
+		s.Decisions.Purge()
+	}
+	return swept, nil
+}
+
+// StartAssignmentSweeper runs SweepAssignments every interval until ctx is
+// done.
+func (s *AppService) StartAssignmentSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				swept, err := s.SweepAssignments(ctx)
+				if err != nil {
+					s.Logger.ErrorContext(ctx, "failed to sweep assignments", "error", err)
+					continue
+				}
+				if swept > 0 {
+					s.Logger.InfoContext(ctx, "swept expired assignments", "count", swept)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/secret"
+)
+
+// audit stores the event. A failure to store it is logged and doesn't fail
+// the audited operation.
+func (s *AppService) audit(event model.AuditEvent) {
+	if err := s.DB.Create(&event).Error; err != nil {
+		s.Logger.Error("failed to store audit event", "error", err, "action", event.Action)
+		return
+	}
+	s.Logger.Info("audit",
+		"actor_id", event.ActorID,
+		"action", event.Action,
+		"target_type", event.TargetType,
+		"target_id", event.TargetID,
+		"outcome", event.Outcome,
+		"reason", event.Reason,
+	)
+}
+
+// auditRejection records err when it is a privilege escalation and returns
+// it unchanged. Call it outside the transaction that failed, so that the
+// event isn't rolled back with it.
+func (s *AppService) auditRejection(editor *secret.JwtClaims, action, targetType, targetID string, err error) error {
+	var escalation *EscalationError
+	if !errors.As(err, &escalation) {
+		return err
+	}
+	var actorID string
+	if editor != nil {
+		actorID = editor.ID
+	}
+	s.audit(model.AuditEvent{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Outcome:    model.AuditOutcomeDenied,
+		Reason:     escalation.Error(),
+		Details: map[string]any{
+			"permissions": escalation.Permissions,
+			"tenants":     escalation.Tenants,
+		},
+	})
+	return err
+}
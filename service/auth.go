@@ -1,33 +1,83 @@
 package service
 
 import (
+	"cmp"
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
+	"time"
 
+	"github.com/go-gorote/auth/base"
 	"github.com/go-gorote/auth/model"
 	"github.com/go-gorote/auth/schema"
-	"github.com/go-gorote/gorote"
 )
 
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// Login authenticates the user. A password older than the policy allows
+// fails with ErrPasswordExpired.
 func (s *AppService) Login(req *schema.Login) (*model.User, error) {
-	var user model.User
+	user, err := s.authenticate(cmp.Or(req.Identifier, req.Email), req.Password)
+	if err != nil {
+		return nil, err
+	}
+	if s.PasswordPolicyFor(user).Expired(user.PasswordSetAt(), time.Now()) {
+		return nil, fmt.Errorf("failed to login: %w", ErrPasswordExpired)
+	}
+	return user, nil
+}
+
+// authenticate checks the credentials of an active user, identified by
+// any of the allowed LoginIdentifiers. Users in another status get the
+// secret.ErrAccount error of it, only once their password is checked.
+func (s *AppService) authenticate(identifier, password string) (*model.User, error) {
+	column, value, ok := s.identifierColumn(identifier)
+	if !ok {
+		return nil, fmt.Errorf("failed to login: username or password is incorrect")
+	}
+	var users []model.User
 	result := s.DB.
 		Preload("Roles.Permissions").
+		Preload("Roles.Grants").
 		Preload("Tenants").
-		Where("email = ?", req.Email).
-		First(&user)
-	if result.Error != nil {
+		Preload("RoleAssignments").
+		Preload("TenantAssignments").
+		Preload("Groups.Roles.Permissions").
+		Preload("Groups.Roles.Grants").
+		Preload("Groups.Tenants").
+		Where(fmt.Sprintf("%s = ?", column), value).
+		Limit(2).
+		Find(&users)
+	// A phone shared by several users identifies none of them.
+	if result.Error != nil || len(users) != 1 {
 		return nil, fmt.Errorf("failed to login: username or password is incorrect")
 	}
+	user := users[0]
 
-	if !gorote.CheckPasswordHash(req.Password, user.Password) {
+	ok, rehash := s.verifyPassword(password, user.Password)
+	if !ok {
 		return nil, fmt.Errorf("failed to login: username or password is incorrect")
 	}
 
-	if !user.Active {
-		return nil, fmt.Errorf("failed to login: user is inactive")
+	now := time.Now()
+	if err := user.AccountError(now); err != nil {
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+	if user.Status != model.UserActive {
+		s.endSuspension(&user)
+	}
+	if rehash {
+		s.rehashPassword(&user, password)
 	}
 
+	activeGrants(&user)
+	return &user, nil
+}
+
+// activeGrants drops the inactive tenants, roles and permissions of the
+// user, which a token doesn't carry.
+func activeGrants(user *model.User) {
 	user.Tenants = slices.DeleteFunc(user.Tenants, func(t model.Tenant) bool {
 		return !t.Active
 	})
@@ -36,10 +86,48 @@ func (s *AppService) Login(req *schema.Login) (*model.User, error) {
 		return !r.Active
 	})
 
-	for _, r := range user.Roles {
-		r.Permissions = slices.DeleteFunc(r.Permissions, func(p model.Permission) bool {
+	for i := range user.Roles {
+		user.Roles[i].Permissions = slices.DeleteFunc(user.Roles[i].Permissions, func(p model.Permission) bool {
 			return !p.Active
 		})
 	}
-	return &user, nil
+}
+
+// identifierColumn returns the column of users the identifier is matched
+// against, by its shape, and the identifier normalized for it: emails have
+// an @, phones are in E.164 and usernames have neither. It fails when the
+// kind isn't allowed.
+func (s *AppService) identifierColumn(identifier string) (string, string, bool) {
+	identifier = strings.TrimSpace(identifier)
+	var kind base.LoginIdentifier
+	var column string
+	switch {
+	case strings.Contains(identifier, "@"):
+		kind, column, identifier = base.LoginEmail, "email", model.NormalizeEmail(identifier)
+	case e164.MatchString(identifier):
+		kind, column = base.LoginPhone, "phone1"
+	default:
+		kind, column, identifier = base.LoginUsername, "username", model.NormalizeUsername(identifier)
+	}
+	allowed := s.LoginIdentifiers
+	if len(allowed) == 0 {
+		allowed = []base.LoginIdentifier{base.LoginEmail, base.LoginUsername}
+	}
+	return column, identifier, slices.Contains(allowed, kind)
+}
+
+// rehashPassword replaces an outdated hash of the user's password. The
+// password itself is unchanged, so updated_at and the password age are
+// left alone and a failure only gets logged.
+func (s *AppService) rehashPassword(user *model.User, password string) {
+	hash, err := s.hashPassword(password)
+	if err != nil {
+		s.Logger.Error("failed to rehash password", "error", err, "user_id", user.ID.String())
+		return
+	}
+	if err := s.DB.Model(user).UpdateColumn("password", hash).Error; err != nil {
+		s.Logger.Error("failed to rehash password", "error", err, "user_id", user.ID.String())
+		return
+	}
+	user.Password = hash
 }
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+)
+
+const defaultDecisionTTL = 30 * time.Second
+
+// DecisionCache keeps authorization decisions for a short time so repeated
+// checks from other services do not hit the database.
+type DecisionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedDecision
+}
+
+type cachedDecision struct {
+	decision secret.Decision
+	expires  time.Time
+}
+
+func NewDecisionCache(ttl time.Duration) *DecisionCache {
+	if ttl <= 0 {
+		ttl = defaultDecisionTTL
+	}
+	return &DecisionCache{ttl: ttl, entries: map[string]cachedDecision{}}
+}
+
+func (c *DecisionCache) Get(key string) (secret.Decision, bool) {
+	if c == nil {
+		return secret.Decision{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return secret.Decision{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return secret.Decision{}, false
+	}
+	return entry.decision, true
+}
+
+// Set keeps the decision for the ttl of the cache, or until the given time
+// when it comes first, e.g. the expiry of the token the decision is for.
+func (c *DecisionCache) Set(key string, decision secret.Decision, until ...time.Time) {
+	if c == nil {
+		return
+	}
+	expires := time.Now().Add(c.ttl)
+	for _, u := range until {
+		if u.Before(expires) {
+			expires = u
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedDecision{decision: decision, expires: expires}
+}
+
+// Purge drops every cached decision. It is called whenever users, roles or
+// permissions change.
+func (c *DecisionCache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]cachedDecision{}
+}
+
+// CheckAccess evaluates the permission codes and tenant for the subject or
+// the token of the request with secret.Authorize and the account status,
+// as the route middlewares do.
+func (s *AppService) CheckAccess(req *schema.AuthzCheck) (*secret.Decision, error) {
+	key := decisionKey(req)
+	if decision, ok := s.Decisions.Get(key); ok {
+		return &decision, nil
+	}
+
+	var claims *secret.JwtClaims
+	if req.Token != "" {
+		claims = &secret.JwtClaims{}
+		if err := s.Claims(claims, req.Token); err != nil {
+			decision := secret.Decision{Reason: "invalid token: " + err.Error()}
+			return &decision, nil
+		}
+	} else {
+		users, err := s.Users(req.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("subject not found")
+		}
+		user := users[0]
+		// The same grants as a token issued at login.
+		activeGrants(&user)
+		if err := user.AccountError(time.Now()); err != nil {
+			decision := secret.Decision{Status: secret.AccountStatus(err), Reason: err.Error()}
+			s.Decisions.Set(key, decision)
+			return &decision, nil
+		}
+		if claims, err = s.ClaimsFor(&user, "access_token"); err != nil {
+			return nil, err
+		}
+	}
+
+	var tenant *string
+	if req.Tenant != "" {
+		tenant = &req.Tenant
+	}
+	codes := make([]permission.PermissionCode, 0, len(req.Permissions))
+	for _, code := range req.Permissions {
+		codes = append(codes, permission.PermissionCode(code))
+	}
+	decision := secret.Authorize(claims, tenant, codes...)
+	// Tokens outlive the account of their user, as in the route middlewares.
+	if decision.Allowed && req.Token != "" {
+		if err := s.AccountStatus(claims.ID); err != nil {
+			decision = secret.Decision{Status: secret.AccountStatus(err), Reason: err.Error()}
+		}
+	}
+	// A decision for a token doesn't outlive it, nor one for a subject the
+	// grants it was made with.
+	if claims.ExpiresAt != nil {
+		s.Decisions.Set(key, decision, claims.ExpiresAt.Time)
+	} else {
+		s.Decisions.Set(key, decision)
+	}
+	return &decision, nil
+}
+
+func decisionKey(req *schema.AuthzCheck) string {
+	subject := "subject:" + req.SubjectID
+	if req.Token != "" {
+		sum := sha256.Sum256([]byte(req.Token))
+		subject = "token:" + hex.EncodeToString(sum[:])
+	}
+	codes := slices.Clone(req.Permissions)
+	slices.Sort(codes)
+	return strings.Join([]string{subject, req.Tenant, strings.Join(codes, ",")}, "|")
+}
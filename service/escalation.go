@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/secret"
+	"github.com/google/uuid"
+)
+
+// ErrEscalation is wrapped by every EscalationError.
+var ErrEscalation = errors.New("privilege escalation")
+
+// EscalationError is returned when a non-super editor grants permissions
+// they don't hold or tenants they don't belong to.
+type EscalationError struct {
+	Permissions []string
+	Tenants     []string
+}
+
+func (e *EscalationError) Error() string {
+	var parts []string
+	if len(e.Permissions) > 0 {
+		parts = append(parts, "you don't hold "+strings.Join(e.Permissions, ", "))
+	}
+	if len(e.Tenants) > 0 {
+		parts = append(parts, "you don't belong to "+strings.Join(e.Tenants, ", "))
+	}
+	return fmt.Sprintf("%s: %s", ErrEscalation, strings.Join(parts, "; "))
+}
+
+func (e *EscalationError) Unwrap() error {
+	return ErrEscalation
+}
+
+// guardGrant checks that the editor holds every permission code and belongs
+// to every tenant being granted. Super users, and calls without an editor
+// such as seeding, are not restricted; the admin permission counts as
+// holding every code.
+func guardGrant(editor *secret.JwtClaims, codes []string, tenants []model.Tenant) error {
+	if editor == nil || editor.IsSuperUser {
+		return nil
+	}
+	var missing, foreign []string
+	for _, code := range codes {
+		if !editor.HasPermission(permission.PermissionAdmin, permission.PermissionCode(code)) && !slices.Contains(missing, code) {
+			missing = append(missing, code)
+		}
+	}
+	for _, tenant := range tenants {
+		if !slices.Contains(editor.Tenants, tenant.Name) && !slices.Contains(foreign, tenant.Name) {
+			foreign = append(foreign, tenant.Name)
+		}
+	}
+	if len(missing) == 0 && len(foreign) == 0 {
+		return nil
+	}
+	return &EscalationError{Permissions: missing, Tenants: foreign}
+}
+
+// guardRoles checks the codes the roles allow, including inactive roles and
+// permissions, since those can be activated later.
+func guardRoles(editor *secret.JwtClaims, roles []model.Role, tenants []model.Tenant) error {
+	var codes []string
+	for _, role := range roles {
+		codes = append(codes, allowedCodes(&role)...)
+	}
+	return guardGrant(editor, codes, tenants)
+}
+
+func allowedCodes(role *model.Role) []string {
+	var codes []string
+	for _, p := range role.Permissions {
+		if role.EffectOf(p.ID) != model.EffectDeny {
+			codes = append(codes, p.Code)
+		}
+	}
+	return codes
+}
+
+// added returns the items of after that are missing from before.
+func added[T any](before, after []T, id func(T) uuid.UUID) []T {
+	var items []T
+	for _, item := range after {
+		if !slices.ContainsFunc(before, func(b T) bool { return id(b) == id(item) }) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func roleID(r model.Role) uuid.UUID     { return r.ID }
+func tenantID(t model.Tenant) uuid.UUID { return t.ID }
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"gorm.io/gorm"
+)
+
+func (s *AppService) Groups(ids ...string) ([]model.Group, error) {
+	var data []model.Group
+	query := s.DB.
+		Preload("Users").
+		Preload("Roles.Permissions").
+		Preload("Roles.Grants").
+		Preload("Tenants")
+	if len(ids) == 0 {
+		if err := query.Find(&data).Error; err != nil {
+			return nil, fmt.Errorf("failed to query database")
+		}
+		return data, nil
+	}
+	if err := query.Where("id IN ?", ids).Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch groups")
+	}
+	return data, nil
+}
+
+// CreateGroup creates the group on behalf of editor, who may only attach
+// roles and tenants within their own access.
+func (s *AppService) CreateGroup(req *schema.CreateGroup, editor *secret.JwtClaims) (*model.Group, error) {
+	group := model.Group{
+		Name:        req.Name,
+		Description: req.Description,
+		Active:      req.Active,
+	}
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if len(req.Roles) > 0 {
+			if err := tx.Preload("Permissions").Preload("Grants").Where("id IN ?", req.Roles).Find(&group.Roles).Error; err != nil {
+				return fmt.Errorf("failed to fetch roles")
+			}
+		}
+		if len(req.Tenants) > 0 {
+			if err := tx.Where("id IN ?", req.Tenants).Find(&group.Tenants).Error; err != nil {
+				return fmt.Errorf("failed to fetch tenants")
+			}
+		}
+		if err := guardRoles(editor, group.Roles, group.Tenants); err != nil {
+			return err
+		}
+		if err := tx.Create(&group).Error; err != nil {
+			return fmt.Errorf("failed to create group")
+		}
+		return nil
+	}); err != nil {
+		return nil, s.auditRejection(editor, "create_group", "group", req.Name, err)
+	}
+	return &group, nil
+}
+
+// UpdateGroup updates the group on behalf of editor, who may only attach
+// roles and tenants within their own access.
+func (s *AppService) UpdateGroup(req *schema.UpdateGroup, editor *secret.JwtClaims) (*model.Group, error) {
+	var group model.Group
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		groups, err := s.Groups(req.ID)
+		if err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			return fmt.Errorf("no groups found")
+		}
+		group = groups[0]
+		before, beforeTenants := group.Roles, group.Tenants
+
+		group.Name = req.Name
+		group.Description = req.Description
+		group.Active = req.Active
+		group.Roles = nil
+		group.Tenants = nil
+		if len(req.Roles) > 0 {
+			if err := tx.Preload("Permissions").Preload("Grants").Where("id IN ?", req.Roles).Find(&group.Roles).Error; err != nil {
+				return fmt.Errorf("failed to fetch roles")
+			}
+		}
+		if len(req.Tenants) > 0 {
+			if err := tx.Where("id IN ?", req.Tenants).Find(&group.Tenants).Error; err != nil {
+				return fmt.Errorf("failed to fetch tenants")
+			}
+		}
+
+		if err := guardRoles(editor, added(before, group.Roles, roleID), added(beforeTenants, group.Tenants, tenantID)); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&group).Omit("Users", "Roles", "Tenants").Select("*").Updates(group).Error; err != nil {
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+		if err := tx.Model(&group).Association("Roles").Replace(group.Roles); err != nil {
+			return fmt.Errorf("failed to update roles: %w", err)
+		}
+		if err := tx.Model(&group).Association("Tenants").Replace(group.Tenants); err != nil {
+			return fmt.Errorf("failed to update tenants: %w", err)
+		}
+
+		members := groupMemberIDs(&group)
+		if err := s.enforceSodForUsers(tx, members); err != nil {
+			return err
+		}
+		return touchUsers(tx, members)
+	}); err != nil {
+		return nil, s.auditRejection(editor, "update_group", "group", req.ID, err)
+	}
+
+	s.Decisions.Purge()
+	return &group, nil
+}
+
+// AddGroupMembers adds the users on behalf of editor. Members inherit the
+// group's roles and tenants, so the editor must be able to grant them.
+func (s *AppService) AddGroupMembers(req *schema.GroupMembers, editor *secret.JwtClaims) (*model.Group, error) {
+	var group model.Group
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("Roles.Permissions").
+			Preload("Roles.Grants").
+			Preload("Tenants").
+			First(&group, "id = ?", req.ID).Error; err != nil {
+			return fmt.Errorf("no groups found")
+		}
+		if err := guardRoles(editor, group.Roles, group.Tenants); err != nil {
+			return err
+		}
+		var users []model.User
+		if err := tx.Where("id IN ?", req.Users).Find(&users).Error; err != nil {
+			return fmt.Errorf("failed to fetch users")
+		}
+		if len(users) != len(mergeIDs(req.Users)) {
+			return fmt.Errorf("some users were not found")
+		}
+		if err := tx.Model(&group).Association("Users").Append(&users); err != nil {
+			return fmt.Errorf("failed to add members: %w", err)
+		}
+		return s.enforceSodForUsers(tx, req.Users)
+	}); err != nil {
+		return nil, s.auditRejection(editor, "add_group_members", "group", req.ID, err)
+	}
+
+	s.Decisions.Purge()
+	return s.group(group.ID.String())
+}
+
+// RemoveGroupMembers removes the users from the group and revokes their
+// refresh tokens, since they lose the group's grants.
+func (s *AppService) RemoveGroupMembers(req *schema.GroupMembers) (*model.Group, error) {
+	var group model.Group
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&group, "id = ?", req.ID).Error; err != nil {
+			return fmt.Errorf("no groups found")
+		}
+		if err := tx.Table("groups_users").
+			Where("group_id = ? AND user_id IN ?", group.ID, req.Users).
+			Delete(nil).Error; err != nil {
+			return fmt.Errorf("failed to remove members")
+		}
+		return touchUsers(tx, req.Users)
+	}); err != nil {
+		return nil, err
+	}
+
+	s.Decisions.Purge()
+	return s.group(group.ID.String())
+}
+
+// DeleteGroup deletes the group with its memberships and role and tenant
+// links, and revokes the refresh tokens of its members, who lose its grants.
+func (s *AppService) DeleteGroup(req *schema.RecieveGroup, editor *secret.JwtClaims) error {
+	var group model.Group
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Users").First(&group, "id = ?", req.ID).Error; err != nil {
+			return fmt.Errorf("no groups found")
+		}
+		for _, table := range []string{"groups_users", "groups_roles", "groups_tenants"} {
+			if err := tx.Table(table).Where("group_id = ?", group.ID).Delete(nil).Error; err != nil {
+				return fmt.Errorf("failed to delete group links")
+			}
+		}
+		// The name is unique, so the row is removed for good to free it.
+		if err := tx.Unscoped().Delete(&group).Error; err != nil {
+			return fmt.Errorf("failed to delete group")
+		}
+		return touchUsers(tx, groupMemberIDs(&group))
+	}); err != nil {
+		return err
+	}
+
+	s.Decisions.Purge()
+	var actorID string
+	if editor != nil {
+		actorID = editor.ID
+	}
+	s.audit(model.AuditEvent{
+		ActorID:    actorID,
+		Action:     "delete_group",
+		TargetType: "group",
+		TargetID:   group.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"name": group.Name, "members": len(group.Users)},
+	})
+	return nil
+}
+
+func (s *AppService) group(id string) (*model.Group, error) {
+	groups, err := s.Groups(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no groups found")
+	}
+	return &groups[0], nil
+}
+
+func groupMemberIDs(group *model.Group) []string {
+	var ids []string
+	for _, user := range group.Users {
+		ids = append(ids, user.ID.String())
+	}
+	return ids
+}
+
+// touchUsers bumps updated_at, which invalidates the refresh tokens issued
+// to the users before their grants changed.
+func touchUsers(tx *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Model(&model.User{}).
+		Where("id IN ?", ids).
+		Update("updated_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke sessions")
+	}
+	return nil
+}
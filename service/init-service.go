@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
 	"log/slog"
+	"time"
 
 	"github.com/go-gorote/auth/base"
 	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/passwd"
+	"github.com/go-gorote/auth/permission"
+	"github.com/go-gorote/auth/policy"
+	"github.com/go-gorote/auth/rebac"
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"github.com/go-gorote/gorote"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -13,7 +20,11 @@ import (
 
 type AppService struct {
 	base.Config
-	Logger *slog.Logger
+	Logger    *slog.Logger
+	Decisions *DecisionCache
+	// Breached is the corpus opened from BreachedPasswordsFile.
+	Breached passwd.Corpus
+	Statuses *StatusCache
 }
 
 type Service interface {
@@ -22,19 +33,62 @@ type Service interface {
 	SetCookie(*fiber.Ctx, string, string) error
 	DeleteCookie(*fiber.Ctx, string) error
 	GenerateJwt(*model.User, string) (string, error)
+	GenerateJwtAuthenticatedAt(*model.User, string, time.Time) (string, error)
+	ClaimsFor(*model.User, string) (*secret.JwtClaims, error)
 	Login(*schema.Login) (*model.User, error)
 	Users(...string) ([]model.User, error)
+	PermissionGrants(string, time.Time) (*model.User, []model.PermissionGrant, error)
+	UpdateMe(*fiber.Ctx, string, *schema.UpdateMe) (*model.User, error)
+	RequestEmailChange(context.Context, *model.User, string) error
+	RecentAuthMaxAge() time.Duration
+	StepUp(*secret.JwtClaims, string) error
+	Reauthenticate(context.Context, string, *schema.Reauthenticate) (*model.User, error)
+	ConfirmEmailChange(context.Context, string) (*model.User, error)
 	Roles(...string) ([]model.Role, error)
 	Tenants(...string) ([]model.Tenant, error)
 	CreateTenant(*fiber.Ctx, *schema.CreateTenant) (*model.Tenant, error)
 	Permissions(...string) ([]model.Permission, error)
 	CreatePermission(*schema.CreatePermission) (*model.Permission, error)
-	UpdatePermission(*schema.UpdatePermission) (*model.Permission, error)
-	CreateRole(*schema.CreateRole) (*model.Role, error)
-	CreateUser(*fiber.Ctx, *schema.CreateUser, string, bool) (*model.User, error)
-	UpdateUser(*schema.UpdateUser, bool, bool) (*model.User, error)
-	UpdateRole(*schema.UpdateRole) (*model.Role, error)
+	UpdatePermission(*schema.UpdatePermission, *secret.JwtClaims) (*model.Permission, error)
+	CreateRole(*schema.CreateRole, *secret.JwtClaims) (*model.Role, error)
+	CreateUser(*fiber.Ctx, *schema.CreateUser, *secret.JwtClaims) (*model.User, string, error)
+	ImportUsers(*schema.ImportUsers, *secret.JwtClaims) ([]model.User, error)
+	UpdateUser(*schema.UpdateUser, *secret.JwtClaims, bool) (*model.User, error)
+	UpdateRole(*schema.UpdateRole, *secret.JwtClaims) (*model.Role, error)
 	UpdateTenant(*fiber.Ctx, *schema.UpdateTenant) (*model.Tenant, error)
 	ChangePassword(*schema.ChangePassword) error
+	ChangeExpiredPassword(*schema.ChangeExpiredPassword) error
 	Claims(jwt.Claims, string) error
+	Policies(...string) ([]model.Policy, error)
+	CreatePolicy(*schema.CreatePolicy) (*model.Policy, error)
+	UpdatePolicy(*schema.UpdatePolicy) (*model.Policy, error)
+	Authorize(context.Context, policy.Request) (policy.Decision, error)
+	Relations(*schema.ListRelations) ([]model.RelationTuple, error)
+	WriteRelation(*schema.WriteRelation) (*model.RelationTuple, error)
+	DeleteRelation(*schema.WriteRelation) error
+	CheckRelation(context.Context, *schema.CheckRelation) (bool, error)
+	ExpandRelation(context.Context, *schema.ExpandRelation) (*rebac.Node, error)
+	ListObjects(context.Context, *schema.ListObjects) ([]string, error)
+	CheckAccess(*schema.AuthzCheck) (*secret.Decision, error)
+	SweepAssignments(context.Context) (int64, error)
+	ApproverPermission() permission.PermissionCode
+	AccessRequests(*schema.ListAccessRequests, string) ([]model.AccessRequest, error)
+	CreateAccessRequest(context.Context, string, *schema.CreateAccessRequest) (*model.AccessRequest, error)
+	ReviewAccessRequest(context.Context, *secret.JwtClaims, *schema.ReviewAccessRequest, bool) (*model.AccessRequest, error)
+	SodViolations() ([]SodViolation, error)
+	Groups(...string) ([]model.Group, error)
+	CreateGroup(*schema.CreateGroup, *secret.JwtClaims) (*model.Group, error)
+	UpdateGroup(*schema.UpdateGroup, *secret.JwtClaims) (*model.Group, error)
+	AddGroupMembers(*schema.GroupMembers, *secret.JwtClaims) (*model.Group, error)
+	RemoveGroupMembers(*schema.GroupMembers) (*model.Group, error)
+	DeleteGroup(*schema.RecieveGroup, *secret.JwtClaims) error
+	Invitations(*schema.ListInvitations) ([]model.Invitation, error)
+	CreateInvitation(context.Context, *secret.JwtClaims, *schema.CreateInvitation) (*model.Invitation, error)
+	ResendInvitation(context.Context, string) (*model.Invitation, error)
+	RevokeInvitation(*secret.JwtClaims, string) (*model.Invitation, error)
+	AcceptInvitation(context.Context, *schema.AcceptInvitation) (*model.User, error)
+	AccountStatus(string) error
+	ChangeUserStatus(*secret.JwtClaims, *schema.ChangeUserStatus) (*model.User, error)
+	Register(context.Context, *schema.Register, string) error
+	VerifyRegistration(context.Context, string) (*model.User, error)
 }
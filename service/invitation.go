@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/notify"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const NotifyInvitation notify.Kind = "invitation"
+
+func (s *AppService) invitationTTL() time.Duration {
+	if s.InvitationTTL <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return s.InvitationTTL
+}
+
+// Invitations lists the invitations, newest first.
+func (s *AppService) Invitations(req *schema.ListInvitations) ([]model.Invitation, error) {
+	var data []model.Invitation
+	query := s.DB.
+		Preload("Roles").
+		Preload("Tenants").
+		Preload("Inviter").
+		Order("created_at DESC")
+	now := time.Now()
+	switch model.InvitationStatus(req.Status) {
+	case "":
+	case model.InvitationPending:
+		query = query.Where("status = ? AND expires_at > ?", model.InvitationPending, now)
+	case model.InvitationExpired:
+		query = query.Where("status = ? AND expires_at <= ?", model.InvitationPending, now)
+	default:
+		query = query.Where("status = ?", req.Status)
+	}
+	if err := query.Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to query database")
+	}
+	return data, nil
+}
+
+// CreateInvitation invites the owner of an email address that no user has
+// yet. Like any other grant, inviters may only give roles and tenants
+// within their own access.
+func (s *AppService) CreateInvitation(ctx context.Context, inviter *secret.JwtClaims, req *schema.CreateInvitation) (*model.Invitation, error) {
+	inviterID, err := uuid.Parse(inviter.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inviter id")
+	}
+	now := time.Now()
+	data := model.Invitation{
+		Email:     model.NormalizeEmail(req.Email),
+		InviterID: inviterID,
+		Status:    model.InvitationPending,
+		ExpiresAt: now.Add(s.invitationTTL()),
+		Nonce:     uuid.NewString(),
+		SentAt:    now,
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("expires_at must be in the future")
+		}
+		data.ExpiresAt = *req.ExpiresAt
+	}
+
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.invitable(tx, data.Email); err != nil {
+			return err
+		}
+		if len(req.Roles) > 0 {
+			roles, err := s.Roles(req.Roles...)
+			if err != nil {
+				return err
+			}
+			data.Roles = roles
+		}
+		if len(req.Tenants) > 0 {
+			tenants, err := s.Tenants(req.Tenants...)
+			if err != nil {
+				return err
+			}
+			data.Tenants = tenants
+		}
+		if err := guardRoles(inviter, data.Roles, data.Tenants); err != nil {
+			return err
+		}
+		if err := s.enforceSod(data.Roles); err != nil {
+			return err
+		}
+		if err := tx.Omit("Inviter").Create(&data).Error; err != nil {
+			return fmt.Errorf("failed to create invitation")
+		}
+		return tx.First(&data.Inviter, "id = ?", inviterID).Error
+	}); err != nil {
+		return nil, s.auditRejection(inviter, "invite_user", "invitation", data.Email, err)
+	}
+
+	s.audit(model.AuditEvent{
+		ActorID:    inviter.ID,
+		Action:     "invite_user",
+		TargetType: "invitation",
+		TargetID:   data.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"email": data.Email},
+	})
+	if err := s.sendInvitation(ctx, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// invitable checks that neither a user nor a pending invitation other than
+// except has the email.
+func (s *AppService) invitable(tx *gorm.DB, email string, except ...uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to query database")
+	}
+	if count > 0 {
+		return fmt.Errorf("email is already in use")
+	}
+	query := tx.Model(&model.Invitation{}).
+		Where("email = ? AND status = ? AND expires_at > ?", email, model.InvitationPending, time.Now())
+	if len(except) > 0 {
+		query = query.Where("id NOT IN ?", except)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to query database")
+	}
+	if count > 0 {
+		return fmt.Errorf("an invitation for this email is already pending")
+	}
+	return nil
+}
+
+// ResendInvitation sends a new link for a pending invitation, which
+// invalidates the previous ones. An expired invitation gets a new expiry,
+// unless its email was taken by a user or another invitation meanwhile.
+func (s *AppService) ResendInvitation(ctx context.Context, id string) (*model.Invitation, error) {
+	var data model.Invitation
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("Roles").
+			Preload("Tenants").
+			Preload("Inviter").
+			First(&data, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("invitation not found")
+		}
+		if data.Status != model.InvitationPending {
+			return fmt.Errorf("invitation was already %s", data.Status)
+		}
+		if err := s.invitable(tx, data.Email, data.ID); err != nil {
+			return err
+		}
+		now := time.Now()
+		if data.StatusAt(now) == model.InvitationExpired {
+			data.ExpiresAt = now.Add(s.invitationTTL())
+		}
+		data.Nonce, data.SentAt = uuid.NewString(), now
+		if err := tx.Model(&data).Updates(map[string]any{
+			"nonce":      data.Nonce,
+			"sent_at":    data.SentAt,
+			"expires_at": data.ExpiresAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update invitation")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.sendInvitation(ctx, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// RevokeInvitation invalidates a pending invitation and its links.
+func (s *AppService) RevokeInvitation(revoker *secret.JwtClaims, id string) (*model.Invitation, error) {
+	var data model.Invitation
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("Roles").
+			Preload("Tenants").
+			Preload("Inviter").
+			First(&data, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("invitation not found")
+		}
+		if data.Status != model.InvitationPending {
+			return fmt.Errorf("invitation was already %s", data.Status)
+		}
+		data.Status = model.InvitationRevoked
+		if err := tx.Model(&data).Update("status", data.Status).Error; err != nil {
+			return fmt.Errorf("failed to update invitation")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	s.audit(model.AuditEvent{
+		ActorID:    revoker.ID,
+		Action:     "revoke_invitation",
+		TargetType: "invitation",
+		TargetID:   data.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"email": data.Email},
+	})
+	return &data, nil
+}
+
+// AcceptInvitation creates the invited user from a link sent by
+// CreateInvitation or ResendInvitation, with the roles and tenants of the
+// invitation.
+func (s *AppService) AcceptInvitation(ctx context.Context, req *schema.AcceptInvitation) (*model.User, error) {
+	var claims secret.InvitationClaims
+	if err := s.Claims(&claims, req.Token); err != nil || claims.Type != "invitation" {
+		return nil, fmt.Errorf("invalid or expired invitation link")
+	}
+	var user model.User
+	var invitation model.Invitation
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("Roles").
+			Preload("Tenants").
+			First(&invitation, "id = ?", claims.ID).Error; err != nil {
+			return fmt.Errorf("invalid or expired invitation link")
+		}
+		now := time.Now()
+		if invitation.StatusAt(now) != model.InvitationPending || invitation.Nonce != claims.Nonce {
+			return fmt.Errorf("invalid or expired invitation link")
+		}
+		var count int64
+		if err := tx.Model(&model.User{}).Where("email = ?", invitation.Email).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to query database")
+		}
+		if count > 0 {
+			return fmt.Errorf("email is already in use")
+		}
+
+		user.Email = invitation.Email
+		user.Username = model.NormalizeUsername(req.Username)
+		user.FirstName = req.FirstName
+		user.LastName = req.LastName
+		user.Phone1 = req.Phone1
+		user.Phone2 = req.Phone2
+		user.Roles = invitation.Roles
+		user.Tenants = invitation.Tenants
+		if err := s.enforceSod(user.Roles); err != nil {
+			return err
+		}
+
+		// The policy depends on the tenants of the user.
+		if err := s.checkPassword(tx, &user, req.Password); err != nil {
+			return err
+		}
+		hash, err := s.hashPassword(req.Password)
+		if err != nil {
+			return err
+		}
+		user.Password, user.PasswordChangedAt = hash, &now
+
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create user")
+		}
+		invitation.Status, invitation.UserID, invitation.AcceptedAt = model.InvitationAccepted, &user.ID, &now
+		if err := tx.Model(&invitation).Updates(map[string]any{
+			"status":      invitation.Status,
+			"user_id":     invitation.UserID,
+			"accepted_at": invitation.AcceptedAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update invitation")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.audit(model.AuditEvent{
+		ActorID:    user.ID.String(),
+		Action:     "accept_invitation",
+		TargetType: "invitation",
+		TargetID:   invitation.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"email": user.Email, "inviter_id": invitation.InviterID.String()},
+	})
+	return &user, nil
+}
+
+// sendInvitation signs a link for the current nonce of the invitation and
+// sends it to the invitee.
+func (s *AppService) sendInvitation(ctx context.Context, invitation *model.Invitation) error {
+	token, err := gorote.GenerateJwtWithRSA(&secret.InvitationClaims{
+		Email: invitation.Email,
+		Nonce: invitation.Nonce,
+		Type:  "invitation",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        invitation.ID.String(),
+			Issuer:    fmt.Sprintf("%s@%s", s.AppName, s.AppVersion),
+			IssuedAt:  jwt.NewNumericDate(invitation.SentAt),
+			ExpiresAt: jwt.NewNumericDate(invitation.ExpiresAt),
+		},
+	}, s.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign invitation")
+	}
+
+	data := map[string]string{
+		"invitation_id": invitation.ID.String(),
+		"email":         invitation.Email,
+		"inviter":       invitation.Inviter.Username,
+		"token":         token,
+		"expires_at":    invitation.ExpiresAt.Format("02/01/2006 15:04:05"),
+	}
+	if s.InvitationURL != "" {
+		link, err := url.Parse(s.InvitationURL)
+		if err != nil {
+			return fmt.Errorf("invalid invitation url")
+		}
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+		data["url"] = link.String()
+	}
+	s.notify(ctx, notify.Message{
+		Kind:    NotifyInvitation,
+		To:      []notify.Recipient{{Email: invitation.Email}},
+		Subject: fmt.Sprintf("You are invited to %s", s.AppName),
+		Body:    fmt.Sprintf("%s invited you to join %s. The invitation expires at %s.", invitation.Inviter.Username, s.AppName, data["expires_at"]),
+		Data:    data,
+	})
+	return nil
+}
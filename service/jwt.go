@@ -10,40 +10,72 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func (s *AppService) GenerateJwt(user *model.User, typeToken string) (string, error) {
-	var permissions []string
-	for _, role := range user.Roles {
-		for _, permission := range role.Permissions {
-			permissions = append(permissions, permission.Code)
-		}
-	}
+// ClaimsFor builds the claims a token of the given type would carry for the
+// user.
+func (s *AppService) ClaimsFor(user *model.User, typeToken string) (*secret.JwtClaims, error) {
+	now := time.Now()
+	permissions, denied := user.EffectivePermissions()
 	var tenants []string
-	for _, tenant := range user.Tenants {
+	for _, tenant := range user.ActiveTenants(now) {
 		tenants = append(tenants, tenant.Name)
 	}
 
-	var expire time.Duration
+	var expiresAt time.Time
 	switch typeToken {
 	case "access_token":
-		expire = s.JwtExpireAccess
+		expiresAt = now.Add(s.JwtExpireAccess)
+		// An access token never outlives the grants it carries.
+		if until := user.AssignmentsExpireAt(now); until != nil && until.Before(expiresAt) {
+			expiresAt = *until
+		}
 	case "refresh_token":
-		expire = s.JwtExpireRefresh
+		expiresAt = now.Add(s.JwtExpireRefresh)
+	case "password_change_token":
+		// Carries no grants: it only lets the user replace their password.
+		return &secret.JwtClaims{
+			Type:     typeToken,
+			AuthTime: jwt.NewNumericDate(now),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        user.ID.String(),
+				Issuer:    fmt.Sprintf("%s@%s", s.AppName, s.AppVersion),
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(s.JwtExpireAccess)),
+			},
+		}, nil
 	default:
-		return "", fmt.Errorf("invalid token type")
+		return nil, fmt.Errorf("invalid token type")
 	}
 
-	token, err := gorote.GenerateJwtWithRSA(secret.JwtClaims{
+	return &secret.JwtClaims{
 		IsSuperUser: user.IsSuperUser,
 		Permissions: permissions,
+		Denied:      denied,
 		Tenants:     tenants,
 		Type:        typeToken,
+		AuthTime:    jwt.NewNumericDate(now),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        user.ID.String(),
 			Issuer:    fmt.Sprintf("%s@%s", s.AppName, s.AppVersion),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expire)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
-	}, s.PrivateKey)
+	}, nil
+}
+
+func (s *AppService) GenerateJwt(user *model.User, typeToken string) (string, error) {
+	return s.GenerateJwtAuthenticatedAt(user, typeToken, time.Now())
+}
+
+// GenerateJwtAuthenticatedAt is GenerateJwt for a user who last proved their
+// identity at authTime, e.g. when refreshing an access token.
+func (s *AppService) GenerateJwtAuthenticatedAt(user *model.User, typeToken string, authTime time.Time) (string, error) {
+	claims, err := s.ClaimsFor(user, typeToken)
+	if err != nil {
+		return "", err
+	}
+	claims.AuthTime = jwt.NewNumericDate(authTime)
+
+	token, err := gorote.GenerateJwtWithRSA(claims, s.PrivateKey)
 	if err != nil {
 		return "", err
 	}
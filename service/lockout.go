@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/permission"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLockout is wrapped by the errors of changes that would leave nobody
+// able to administer the module. Use the break-glass command to recover if
+// it happens anyway.
+var ErrLockout = errors.New("change would lock out administrators")
+
+// guardLastSuperUser rejects deactivating or demoting the last active super
+// user. before is the user as stored; active and super are the new values.
+func guardLastSuperUser(tx *gorm.DB, before *model.User, active, super bool) error {
+	if !before.IsSuperUser || !before.Active || (active && super) {
+		return nil
+	}
+	// The rows stay locked until tx ends, so that concurrent changes to
+	// two of the last super users can't both see the other one active.
+	var ids []string
+	if err := tx.Model(&model.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("is_super_user = ? AND active = ?", true, true).
+		Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to count super users")
+	}
+	if !slices.ContainsFunc(ids, func(id string) bool { return id != before.ID.String() }) {
+		return fmt.Errorf("%w: %s is the last active super user", ErrLockout, before.Username)
+	}
+	return nil
+}
+
+// guardAdminPermission rejects renaming or deactivating the admin
+// permission.
+func guardAdminPermission(before *model.Permission, code string, active bool) error {
+	if before.Code != string(permission.PermissionAdmin) {
+		return nil
+	}
+	if code != before.Code || !active {
+		return fmt.Errorf("%w: the %s permission can't be renamed or deactivated", ErrLockout, before.Code)
+	}
+	return nil
+}
+
+// guardAdminRole rejects a change that stops the last active role allowing
+// the admin permission from doing so. before is the role as stored and
+// stillAdmin tells whether it allows admin after the change.
+func guardAdminRole(tx *gorm.DB, before *model.Role, stillAdmin bool) error {
+	if stillAdmin || !before.Active || !roleAllowsAdmin(before) {
+		return nil
+	}
+	var count int64
+	if err := tx.Table("roles_permissions").
+		Joins("JOIN roles ON roles.id = roles_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = roles_permissions.permission_id").
+		Where("permissions.code = ? AND roles_permissions.effect = ?", string(permission.PermissionAdmin), model.EffectAllow).
+		Where("roles.active = ? AND roles.deleted_at IS NULL AND roles.id <> ?", true, before.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count admin roles")
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s is the last active role granting %s", ErrLockout, before.Name, permission.PermissionAdmin)
+	}
+	return nil
+}
+
+func roleAllowsAdmin(role *model.Role) bool {
+	for _, code := range allowedCodes(role) {
+		if code == string(permission.PermissionAdmin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/notify"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	NotifyEmailChange  notify.Kind = "email_change"
+	NotifyEmailChanged notify.Kind = "email_changed"
+)
+
+// UpdateMe applies the self-editable fields of req to the user. The email
+// is left alone; see RequestEmailChange.
+func (s *AppService) UpdateMe(ctx *fiber.Ctx, userID string, req *schema.UpdateMe) (*model.User, error) {
+	users, err := s.Users(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("id user not found")
+	}
+	user := users[0]
+
+	updates := map[string]any{}
+	set := func(column string, field *string, value *string) {
+		if value != nil {
+			*field = *value
+			updates[column] = *value
+		}
+	}
+	set("first_name", &user.FirstName, req.FirstName)
+	set("last_name", &user.LastName, req.LastName)
+	set("phone1", &user.Phone1, req.Phone1)
+	set("phone2", &user.Phone2, req.Phone2)
+	set("locale", &user.Locale, req.Locale)
+	if req.Avatar != nil {
+		avatar, err := s.saveAvatar(ctx, req.Avatar)
+		if err != nil {
+			return nil, err
+		}
+		set("avatar", &user.Avatar, &avatar)
+	}
+	if len(updates) == 0 {
+		return &user, nil
+	}
+	// Profile fields aren't carried by tokens, so updated_at is left alone
+	// to keep the refresh tokens of the user valid.
+	if err := s.DB.Model(&user).UpdateColumns(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return &user, nil
+}
+
+// RequestEmailChange sends a signed link to the new address. The email of
+// the user only changes once the link is confirmed.
+func (s *AppService) RequestEmailChange(ctx context.Context, user *model.User, email string) error {
+	email = model.NormalizeEmail(email)
+	var count int64
+	if err := s.DB.Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to query database")
+	}
+	if count > 0 {
+		return fmt.Errorf("email is already in use")
+	}
+
+	ttl := s.EmailChangeTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	now := time.Now()
+	token, err := gorote.GenerateJwtWithRSA(&secret.EmailChangeClaims{
+		Email:    email,
+		Previous: user.Email,
+		Type:     "email_change",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        user.ID.String(),
+			Issuer:    fmt.Sprintf("%s@%s", s.AppName, s.AppVersion),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}, s.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign email confirmation")
+	}
+
+	to := recipients(*user)
+	to[0].Email = email
+	s.notify(ctx, notify.Message{
+		Kind:    NotifyEmailChange,
+		To:      to,
+		Subject: "Confirm your new email address",
+		Body:    fmt.Sprintf("Confirm %s as the new email address of %s.", email, user.Username),
+		Data: map[string]string{
+			"user_id":    user.ID.String(),
+			"email":      email,
+			"token":      token,
+			"expires_at": now.Add(ttl).Format("02/01/2006 15:04:05"),
+		},
+	})
+	return nil
+}
+
+// ConfirmEmailChange sets the email carried by a link from
+// RequestEmailChange. Existing refresh tokens are revoked and the previous
+// address is told about the change.
+func (s *AppService) ConfirmEmailChange(ctx context.Context, token string) (*model.User, error) {
+	var claims secret.EmailChangeClaims
+	if err := s.Claims(&claims, token); err != nil || claims.Type != "email_change" {
+		return nil, fmt.Errorf("invalid or expired confirmation link")
+	}
+	var user model.User
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, "id = ?", claims.ID).Error; err != nil {
+			return fmt.Errorf("id user not found")
+		}
+		if user.Email != claims.Previous {
+			return fmt.Errorf("invalid or expired confirmation link")
+		}
+		var count int64
+		if err := tx.Model(&model.User{}).Where("email = ?", claims.Email).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to query database")
+		}
+		if count > 0 {
+			return fmt.Errorf("email is already in use")
+		}
+		if err := tx.Model(&user).Updates(map[string]any{
+			"email":      claims.Email,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update email: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.audit(model.AuditEvent{
+		ActorID:    user.ID.String(),
+		Action:     "change_email",
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"previous": claims.Previous, "email": claims.Email},
+	})
+	to := recipients(user)
+	to[0].Email = claims.Previous
+	s.notify(ctx, notify.Message{
+		Kind:    NotifyEmailChanged,
+		To:      to,
+		Subject: "Your email address was changed",
+		Body:    fmt.Sprintf("The email address of %s is now %s.", user.Username, claims.Email),
+		Data:    map[string]string{"user_id": user.ID.String(), "email": claims.Email},
+	})
+	return &user, nil
+}
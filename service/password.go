@@ -1,12 +1,22 @@
 package service
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/passwd"
 	"github.com/go-gorote/auth/schema"
-	"github.com/go-gorote/gorote"
+	"gorm.io/gorm"
 )
 
+// ErrPasswordExpired is returned by Login when the password is older than
+// the policy allows. The user sets a new one through ChangeExpiredPassword.
+var ErrPasswordExpired = errors.New("password expired")
+
 func (s *AppService) ChangePassword(req *schema.ChangePassword) error {
 	users, err := s.Users(req.ID)
 	if err != nil {
@@ -17,19 +27,165 @@ func (s *AppService) ChangePassword(req *schema.ChangePassword) error {
 	}
 
 	user := users[0]
+	if user.MustChangePassword {
+		if same, _ := s.verifyPassword(req.Password, user.Password); same {
+			return fmt.Errorf("new password must differ from the temporary one")
+		}
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.checkPassword(tx, &user, req.Password); err != nil {
+			return err
+		}
+		return s.setPassword(tx, &user, req.Password)
+	})
+}
+
+// ChangeExpiredPassword replaces an expired password. Users can't log in
+// with one, so the current credentials are checked here instead.
+func (s *AppService) ChangeExpiredPassword(req *schema.ChangeExpiredPassword) error {
+	user, err := s.authenticate(cmp.Or(req.Identifier, req.Email), req.Password)
+	if err != nil {
+		return err
+	}
+	if req.NewPassword == req.Password {
+		return fmt.Errorf("new password must differ from the current one")
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.checkPassword(tx, user, req.NewPassword); err != nil {
+			return err
+		}
+		return s.setPassword(tx, user, req.NewPassword)
+	})
+}
+
+// PasswordPolicyFor returns the policy the user's passwords follow: the
+// configured policy, or the strictest policy of the user's tenants that
+// define one.
+func (s *AppService) PasswordPolicyFor(user *model.User) passwd.Policy {
+	var tenantPolicies []passwd.Policy
+	for _, tenant := range user.ActiveTenants(time.Now()) {
+		if policy, ok := s.TenantPasswordPolicies[tenant.Name]; ok {
+			tenantPolicies = append(tenantPolicies, policy)
+		}
+	}
+	if len(tenantPolicies) > 0 {
+		return passwd.Strictest(tenantPolicies...)
+	}
+	if s.Config.PasswordPolicy == (passwd.Policy{}) {
+		return passwd.DefaultPolicy
+	}
+	return s.Config.PasswordPolicy
+}
+
+// passwordHashers returns the configured hasher followed by every hasher
+// whose hashes can still be verified, including the legacy ones of imported
+// users.
+func (s *AppService) passwordHashers() []passwd.PasswordHasher {
+	current := s.PasswordHasher
+	if current == nil {
+		current = passwd.DefaultHasher
+	}
+	return append([]passwd.PasswordHasher{current, passwd.Bcrypt{}, passwd.Argon2id{}}, passwd.Legacy()...)
+}
+
+func (s *AppService) hashPassword(password string) (string, error) {
+	hash, err := s.passwordHashers()[0].Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password")
+	}
+	return hash, nil
+}
+
+// verifyPassword checks the password against the stored hash. rehash tells
+// whether the hash should be replaced by one of the configured hasher.
+func (s *AppService) verifyPassword(password, hash string) (ok, rehash bool) {
+	ok, rehash, err := passwd.Verify(password, hash, s.passwordHashers()...)
+	if err != nil {
+		s.Logger.Error("failed to verify password hash", "error", err)
+	}
+	return ok, rehash
+}
 
-	if err := gorote.ValidatePassword(req.Password); err != nil {
+func passwordUserInfo(user *model.User) passwd.UserInfo {
+	return passwd.UserInfo{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Username:  user.Username,
+		Email:     user.Email,
+	}
+}
+
+// checkPassword validates a new password of the user against the policy,
+// the breached passwords and the passwords the policy remembers.
+func (s *AppService) checkPassword(tx *gorm.DB, user *model.User, password string) error {
+	policy := s.PasswordPolicyFor(user)
+	if err := policy.Validate(password, passwordUserInfo(user), s.Breached); err != nil {
 		return err
 	}
+	if policy.History <= 0 || user.Password == "" {
+		return nil
+	}
+	hashes := []string{user.Password}
+	var previous []string
+	if err := tx.Model(&model.PasswordHistory{}).
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Limit(policy.History-1).
+		Pluck("hash", &previous).Error; err != nil {
+		return fmt.Errorf("failed to fetch password history")
+	}
+	hashes = append(hashes, previous...)
+	if slices.ContainsFunc(hashes, func(hash string) bool {
+		ok, _ := s.verifyPassword(password, hash)
+		return ok
+	}) {
+		return &passwd.PolicyError{Problems: []string{
+			fmt.Sprintf("must not be one of the last %d passwords", policy.History),
+		}}
+	}
+	return nil
+}
 
-	hash, err := gorote.HashPassword(req.Password)
+// setPassword stores the hash of the password, remembering the replaced
+// one as long as the policy keeps a history, and lifts the obligation to
+// change it. Updating the user revokes its refresh tokens.
+func (s *AppService) setPassword(tx *gorm.DB, user *model.User, password string) error {
+	hash, err := s.hashPassword(password)
 	if err != nil {
 		return err
 	}
+	policy := s.PasswordPolicyFor(user)
+	if policy.History > 1 && user.Password != "" {
+		if err := tx.Create(&model.PasswordHistory{UserID: user.ID, Hash: user.Password}).Error; err != nil {
+			return fmt.Errorf("failed to store password history")
+		}
+	}
+	var keep []string
+	if policy.History > 1 {
+		if err := tx.Model(&model.PasswordHistory{}).
+			Where("user_id = ?", user.ID).
+			Order("created_at DESC").
+			Limit(policy.History-1).
+			Pluck("id", &keep).Error; err != nil {
+			return fmt.Errorf("failed to fetch password history")
+		}
+	}
+	trim := tx.Unscoped().Where("user_id = ?", user.ID)
+	if len(keep) > 0 {
+		trim = trim.Where("id NOT IN ?", keep)
+	}
+	if err := trim.Delete(&model.PasswordHistory{}).Error; err != nil {
+		return fmt.Errorf("failed to trim password history")
+	}
 
-	if err := s.DB.Model(&user).Update("password", hash).Error; err != nil {
+	now := time.Now()
+	if err := tx.Model(user).Updates(map[string]any{
+		"password":             hash,
+		"password_changed_at":  now,
+		"must_change_password": false,
+	}).Error; err != nil {
 		return fmt.Errorf("failed to update user password: %w", err)
 	}
-
+	user.Password, user.PasswordChangedAt, user.MustChangePassword = hash, &now, false
 	return nil
 }
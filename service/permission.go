@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-gorote/auth/model"
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"gorm.io/gorm"
 )
 
@@ -31,7 +32,9 @@ func (s *AppService) CreatePermission(req *schema.CreatePermission) (*model.Perm
 	if err := s.DB.Transaction(func(tx *gorm.DB) error {
 		permission.Code = req.Code
 		permission.Description = req.Description
+		permission.Group = req.Group
 		permission.Active = req.Active
+		permission.ManagedBy = model.ManagedByUser
 
 		if err := tx.Create(&permission).Error; err != nil {
 			return fmt.Errorf("failed to create permission")
@@ -45,7 +48,10 @@ func (s *AppService) CreatePermission(req *schema.CreatePermission) (*model.Perm
 	return &permission, nil
 }
 
-func (s *AppService) UpdatePermission(req *schema.UpdatePermission) (*model.Permission, error) {
+// UpdatePermission updates the permission on behalf of editor. Renaming or
+// activating a permission changes what every role linked to it grants, so
+// the editor must hold the codes involved.
+func (s *AppService) UpdatePermission(req *schema.UpdatePermission, editor *secret.JwtClaims) (*model.Permission, error) {
 	var permission model.Permission
 	if err := s.DB.Transaction(func(tx *gorm.DB) error {
 		if req.ID == "" {
@@ -60,8 +66,22 @@ func (s *AppService) UpdatePermission(req *schema.UpdatePermission) (*model.Perm
 		}
 		permission = permissions[0]
 
+		if err := guardAdminPermission(&permissions[0], req.Code, req.Active); err != nil {
+			return err
+		}
+		var codes []string
+		if permission.Code != req.Code {
+			codes = append(codes, permission.Code, req.Code)
+		} else if !permission.Active && req.Active {
+			codes = append(codes, req.Code)
+		}
+		if err := guardGrant(editor, codes, nil); err != nil {
+			return err
+		}
+
 		permission.Code = req.Code
 		permission.Description = req.Description
+		permission.Group = req.Group
 		permission.Active = req.Active
 
 		if err := tx.Model(&permission).Select("*").Updates(permission).Error; err != nil {
@@ -70,8 +90,9 @@ func (s *AppService) UpdatePermission(req *schema.UpdatePermission) (*model.Perm
 
 		return nil
 	}); err != nil {
-		return nil, err
+		return nil, s.auditRejection(editor, "update_permission", "permission", req.ID, err)
 	}
 
+	s.Decisions.Purge()
 	return &permission, nil
 }
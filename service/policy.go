@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/policy"
+	"github.com/go-gorote/auth/schema"
+	"gorm.io/gorm"
+)
+
+func (s *AppService) Policies(ids ...string) ([]model.Policy, error) {
+	var data []model.Policy
+	if len(ids) == 0 {
+		if err := s.DB.
+			Find(&data).Error; err != nil {
+			return nil, fmt.Errorf("failed to query database")
+		}
+		return data, nil
+	}
+	if err := s.DB.
+		Where("id IN ?", ids).
+		Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch policies")
+	}
+	return data, nil
+}
+
+func (s *AppService) CreatePolicy(req *schema.CreatePolicy) (*model.Policy, error) {
+	data := model.Policy{
+		Name:        req.Name,
+		Description: req.Description,
+		Effect:      req.Effect,
+		Actions:     req.Actions,
+		Resource:    req.Resource,
+		Conditions:  req.Conditions,
+		Active:      req.Active,
+	}
+	if err := data.ToRule().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := s.DB.Create(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to create policy")
+	}
+	return &data, nil
+}
+
+func (s *AppService) UpdatePolicy(req *schema.UpdatePolicy) (*model.Policy, error) {
+	var data model.Policy
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		policies, err := s.Policies(req.ID)
+		if err != nil {
+			return err
+		}
+		if len(policies) == 0 {
+			return fmt.Errorf("no policies found")
+		}
+		data = policies[0]
+
+		data.Name = req.Name
+		data.Description = req.Description
+		data.Effect = req.Effect
+		data.Actions = req.Actions
+		data.Resource = req.Resource
+		data.Conditions = req.Conditions
+		data.Active = req.Active
+		if err := data.ToRule().Validate(); err != nil {
+			return fmt.Errorf("invalid policy: %w", err)
+		}
+
+		if err := tx.Model(&data).Select("*").Updates(data).Error; err != nil {
+			return fmt.Errorf("failed to update policy: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Authorize evaluates the active policies against the request. Super users
+// are allowed without evaluation, as in secret.ProtectedRoute.
+func (s *AppService) Authorize(ctx context.Context, req policy.Request) (policy.Decision, error) {
+	if super, _ := req.Subject["is_super_user"].(bool); super {
+		return policy.Decision{Allowed: true, Reason: "super user"}, nil
+	}
+	var policies []model.Policy
+	if err := s.DB.WithContext(ctx).
+		Where("active = ?", true).
+		Find(&policies).Error; err != nil {
+		return policy.Decision{}, fmt.Errorf("failed to fetch policies")
+	}
+	rules := make([]policy.Rule, 0, len(policies))
+	for _, p := range policies {
+		rules = append(rules, p.ToRule())
+	}
+	return policy.Evaluate(req, rules), nil
+}
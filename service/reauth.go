@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+)
+
+// ErrRecentAuthRequired is returned when a sensitive change needs the
+// current password or a token issued by a recent authentication.
+var ErrRecentAuthRequired = errors.New("recent authentication required")
+
+// RecentAuthMaxAge returns how long after authenticating a user may make
+// sensitive changes without the current password.
+func (s *AppService) RecentAuthMaxAge() time.Duration {
+	if s.Config.RecentAuthMaxAge <= 0 {
+		return 5 * time.Minute
+	}
+	return s.Config.RecentAuthMaxAge
+}
+
+// StepUp allows a sensitive change to the user's own account when the
+// current password is given and correct, or, without one, when the token
+// comes from a recent authentication.
+func (s *AppService) StepUp(claims *secret.JwtClaims, currentPassword string) error {
+	if currentPassword == "" {
+		if claims.AuthenticatedWithin(s.RecentAuthMaxAge()) {
+			return nil
+		}
+		return fmt.Errorf("%w: confirm your current password", ErrRecentAuthRequired)
+	}
+	var user model.User
+	if err := s.DB.Select("id", "password").First(&user, "id = ?", claims.ID).Error; err != nil {
+		return fmt.Errorf("id user not found")
+	}
+	if ok, _ := s.verifyPassword(currentPassword, user.Password); !ok {
+		return fmt.Errorf("%w: current password is incorrect", ErrRecentAuthRequired)
+	}
+	return nil
+}
+
+// Reauthenticate verifies the password, or the second factor code when an
+// MFA verifier is configured, of an authenticated user so that new tokens
+// can be issued with a fresh auth_time.
+func (s *AppService) Reauthenticate(ctx context.Context, userID string, req *schema.Reauthenticate) (*model.User, error) {
+	users, err := s.Users(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("id user not found")
+	}
+	user := users[0]
+	if !user.Active {
+		return nil, fmt.Errorf("failed to reauthenticate: user is inactive")
+	}
+	switch {
+	case req.Password != "":
+		if ok, _ := s.verifyPassword(req.Password, user.Password); !ok {
+			return nil, fmt.Errorf("failed to reauthenticate: password is incorrect")
+		}
+	case s.MFAVerifier == nil:
+		return nil, fmt.Errorf("failed to reauthenticate: second factor is not configured")
+	default:
+		if err := s.MFAVerifier(ctx, userID, req.Code); err != nil {
+			return nil, fmt.Errorf("failed to reauthenticate: %w", err)
+		}
+	}
+	return &user, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/notify"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"github.com/go-gorote/gorote"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRegistrationDisabled = errors.New("registration is disabled")
+	ErrEmailDomain          = errors.New("email domain is not allowed to register")
+	ErrCaptcha              = errors.New("captcha verification failed")
+)
+
+const (
+	NotifyRegistration         notify.Kind = "registration"
+	NotifyRegistrationExisting notify.Kind = "registration_existing"
+)
+
+// errRegistered marks a registration whose email or username is taken.
+var errRegistered = errors.New("email or username is already in use")
+
+func (s *AppService) registrationTTL() time.Duration {
+	if s.RegistrationTTL <= 0 {
+		return 24 * time.Hour
+	}
+	return s.RegistrationTTL
+}
+
+// Register creates an account for whoever asks, when SelfRegistration is
+// on, with the configured roles and tenants. With RegistrationVerification
+// the account stays pending until the link sent to its email is followed.
+//
+// A taken email or username isn't reported, so that registrations can't
+// tell which accounts exist: the owners are notified instead and the
+// request succeeds as if the account was created.
+func (s *AppService) Register(ctx context.Context, req *schema.Register, remoteIP string) error {
+	if !s.SelfRegistration {
+		return ErrRegistrationDisabled
+	}
+	email, username := model.NormalizeEmail(req.Email), model.NormalizeUsername(req.Username)
+	if !s.registrableEmail(email) {
+		return ErrEmailDomain
+	}
+	if s.CaptchaVerifier != nil {
+		if err := s.CaptchaVerifier(ctx, req.Captcha, remoteIP); err != nil {
+			return fmt.Errorf("%w: %w", ErrCaptcha, err)
+		}
+	}
+
+	var user model.User
+	var existing []model.User
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		user.Email = email
+		user.Username = username
+		user.FirstName = req.FirstName
+		user.LastName = req.LastName
+		user.Phone1 = req.Phone1
+		user.Phone2 = req.Phone2
+		user.Status = model.UserActive
+		if s.RegistrationVerification {
+			user.Status = model.UserPending
+		}
+
+		if roles := mergeIDs(s.RegistrationRoles); len(roles) > 0 {
+			if err := tx.
+				Preload("Permissions").
+				Preload("Grants").
+				Where("name IN ? AND active = ?", roles, true).
+				Find(&user.Roles).Error; err != nil || len(user.Roles) != len(roles) {
+				return fmt.Errorf("failed to register: default roles are missing")
+			}
+		}
+		if tenants := mergeIDs(s.RegistrationTenants); len(tenants) > 0 {
+			if err := tx.
+				Where("name IN ? AND active = ?", tenants, true).
+				Find(&user.Tenants).Error; err != nil || len(user.Tenants) != len(tenants) {
+				return fmt.Errorf("failed to register: default tenants are missing")
+			}
+		}
+		if err := s.enforceSod(user.Roles); err != nil {
+			return err
+		}
+
+		// The policy depends on the tenants of the user.
+		if err := s.checkPassword(tx, &user, req.Password); err != nil {
+			return err
+		}
+		hash, err := s.hashPassword(req.Password)
+		if err != nil {
+			return fmt.Errorf("crypting password failed")
+		}
+		now := time.Now()
+		user.Password, user.PasswordChangedAt = hash, &now
+
+		// Checked once the password is, so that taken and free accounts
+		// fail and take as long alike.
+		if err := tx.Where("email = ? OR username = ?", email, username).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to query database")
+		}
+		if len(existing) > 0 {
+			return errRegistered
+		}
+
+		if s.OnRegister != nil {
+			if err := s.OnRegister(ctx, &user); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create user")
+		}
+		return nil
+	}); errors.Is(err, errRegistered) {
+		s.notifyRegistered(ctx, existing, remoteIP)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	s.audit(model.AuditEvent{
+		ActorID:    user.ID.String(),
+		Action:     "register_user",
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"email": user.Email, "ip": remoteIP},
+	})
+	if s.RegistrationVerification {
+		if err := s.sendRegistrationLink(ctx, &user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyRegistered tells the owners of the email or username a registration
+// asked for that someone tried to register with them.
+func (s *AppService) notifyRegistered(ctx context.Context, users []model.User, remoteIP string) {
+	for _, user := range users {
+		s.audit(model.AuditEvent{
+			Action:     "register_user",
+			TargetType: "user",
+			TargetID:   user.ID.String(),
+			Outcome:    model.AuditOutcomeDenied,
+			Reason:     errRegistered.Error(),
+			Details:    map[string]any{"ip": remoteIP},
+		})
+		s.notify(ctx, notify.Message{
+			Kind:    NotifyRegistrationExisting,
+			To:      recipients(user),
+			Subject: fmt.Sprintf("Someone tried to register with your %s account", s.AppName),
+			Body: fmt.Sprintf("A registration asked for the email or username of your account %s. "+
+				"If it was you, sign in or reset your password instead; otherwise you can ignore this message.", user.Username),
+			Data: map[string]string{
+				"user_id": user.ID.String(),
+				"email":   user.Email,
+				"ip":      remoteIP,
+			},
+		})
+	}
+}
+
+// registrableEmail reports whether the domain of the email is allowed by
+// RegistrationEmailDomains.
+func (s *AppService) registrableEmail(email string) bool {
+	if len(s.RegistrationEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	return slices.ContainsFunc(s.RegistrationEmailDomains, func(d string) bool {
+		return strings.EqualFold(d, domain)
+	})
+}
+
+// VerifyRegistration activates the pending account of a link sent by
+// Register.
+func (s *AppService) VerifyRegistration(ctx context.Context, token string) (*model.User, error) {
+	var claims secret.RegistrationClaims
+	if err := s.Claims(&claims, token); err != nil || claims.Type != "registration" {
+		return nil, fmt.Errorf("invalid or expired verification link")
+	}
+	var user model.User
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, "id = ?", claims.ID).Error; err != nil {
+			return fmt.Errorf("invalid or expired verification link")
+		}
+		// Other users were verified already, or changed by an admin.
+		if user.Status != model.UserPending || user.Email != claims.Email {
+			return fmt.Errorf("invalid or expired verification link")
+		}
+		return s.setStatus(tx, &user, model.UserActive, "", nil)
+	}); err != nil {
+		return nil, err
+	}
+
+	s.auditStatus(user.ID.String(), &user, model.UserPending, "registration verified")
+	s.Decisions.Purge()
+	return &user, nil
+}
+
+// sendRegistrationLink signs a link verifying the email of a new user and
+// sends it there.
+func (s *AppService) sendRegistrationLink(ctx context.Context, user *model.User) error {
+	now := time.Now()
+	expiresAt := now.Add(s.registrationTTL())
+	token, err := gorote.GenerateJwtWithRSA(&secret.RegistrationClaims{
+		Email: user.Email,
+		Type:  "registration",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        user.ID.String(),
+			Issuer:    fmt.Sprintf("%s@%s", s.AppName, s.AppVersion),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}, s.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign verification link")
+	}
+
+	s.notify(ctx, notify.Message{
+		Kind:    NotifyRegistration,
+		To:      recipients(*user),
+		Subject: fmt.Sprintf("Confirm your %s account", s.AppName),
+		Body:    fmt.Sprintf("Confirm %s to activate the account %s.", user.Email, user.Username),
+		Data: map[string]string{
+			"user_id":    user.ID.String(),
+			"email":      user.Email,
+			"token":      token,
+			"expires_at": expiresAt.Format("02/01/2006 15:04:05"),
+		},
+	})
+	return nil
+}
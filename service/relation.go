@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/rebac"
+	"github.com/go-gorote/auth/schema"
+	"github.com/google/uuid"
+)
+
+// Checker returns the relation checker backed by the module database.
+func (s *AppService) Checker() *rebac.Checker {
+	return &rebac.Checker{Store: s, Namespaces: s.Namespaces}
+}
+
+func (s *AppService) Tuples(ctx context.Context, namespace, objectID, relation string) ([]rebac.Tuple, error) {
+	var data []model.RelationTuple
+	if err := s.DB.WithContext(ctx).
+		Where("namespace = ? AND object_id = ? AND relation = ?", namespace, objectID, relation).
+		Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch relation tuples")
+	}
+	tuples := make([]rebac.Tuple, 0, len(data))
+	for _, t := range data {
+		tuples = append(tuples, t.ToTuple())
+	}
+	return tuples, nil
+}
+
+func (s *AppService) Objects(ctx context.Context, namespace string) ([]string, error) {
+	var objects []string
+	if err := s.DB.WithContext(ctx).
+		Model(&model.RelationTuple{}).
+		Where("namespace = ?", namespace).
+		Distinct().
+		Pluck("object_id", &objects).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch relation objects")
+	}
+	return objects, nil
+}
+
+func (s *AppService) RolesOf(ctx context.Context, userID string) ([]string, error) {
+	var roles []string
+	now := time.Now()
+	if err := s.DB.WithContext(ctx).
+		Table("users_roles").
+		Joins("JOIN roles ON roles.id = users_roles.role_id").
+		Where("users_roles.user_id = ? AND roles.active = ? AND roles.deleted_at IS NULL", userID, true).
+		Where("users_roles.starts_at IS NULL OR users_roles.starts_at <= ?", now).
+		Where("users_roles.expires_at IS NULL OR users_roles.expires_at > ?", now).
+		Pluck("users_roles.role_id", &roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user roles")
+	}
+	var inherited []string
+	if err := s.DB.WithContext(ctx).
+		Table("groups_roles").
+		Joins("JOIN groups_users ON groups_users.group_id = groups_roles.group_id").
+		Joins("JOIN user_groups ON user_groups.id = groups_roles.group_id").
+		Joins("JOIN roles ON roles.id = groups_roles.role_id").
+		Where("groups_users.user_id = ? AND user_groups.active = ? AND user_groups.deleted_at IS NULL", userID, true).
+		Where("roles.active = ? AND roles.deleted_at IS NULL", true).
+		Pluck("groups_roles.role_id", &inherited).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user roles")
+	}
+	return mergeIDs(roles, inherited), nil
+}
+
+func (s *AppService) Relations(req *schema.ListRelations) ([]model.RelationTuple, error) {
+	var data []model.RelationTuple
+	query := s.DB.Where("namespace = ?", req.Namespace)
+	if req.ObjectID != "" {
+		query = query.Where("object_id = ?", req.ObjectID)
+	}
+	if req.Relation != "" {
+		query = query.Where("relation = ?", req.Relation)
+	}
+	if err := query.Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to query database")
+	}
+	return data, nil
+}
+
+func (s *AppService) WriteRelation(req *schema.WriteRelation) (*model.RelationTuple, error) {
+	data := model.RelationTuple{
+		Namespace:       req.Namespace,
+		ObjectID:        req.ObjectID,
+		Relation:        req.Relation,
+		SubjectType:     req.SubjectType,
+		SubjectID:       req.SubjectID,
+		SubjectRelation: req.SubjectRelation,
+	}
+	if err := s.Checker().Validate(data.Namespace, data.Relation); err != nil {
+		return nil, err
+	}
+	switch data.SubjectType {
+	case rebac.SubjectUser, rebac.SubjectRole:
+		if data.SubjectRelation != "" {
+			return nil, fmt.Errorf("subject relation is only allowed for usersets")
+		}
+		if _, err := uuid.Parse(data.SubjectID); err != nil {
+			return nil, fmt.Errorf("subject id must be a %s id", data.SubjectType)
+		}
+	default:
+		if data.SubjectRelation != "" {
+			if err := s.Checker().Validate(data.SubjectType, data.SubjectRelation); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.DB.
+		Where(map[string]any{
+			"namespace":        data.Namespace,
+			"object_id":        data.ObjectID,
+			"relation":         data.Relation,
+			"subject_type":     data.SubjectType,
+			"subject_id":       data.SubjectID,
+			"subject_relation": data.SubjectRelation,
+		}).
+		FirstOrCreate(&data).Error; err != nil {
+		return nil, fmt.Errorf("failed to write relation tuple")
+	}
+	return &data, nil
+}
+
+func (s *AppService) DeleteRelation(req *schema.WriteRelation) error {
+	result := s.DB.Unscoped().
+		Where("namespace = ? AND object_id = ? AND relation = ? AND subject_type = ? AND subject_id = ? AND subject_relation = ?",
+			req.Namespace, req.ObjectID, req.Relation, req.SubjectType, req.SubjectID, req.SubjectRelation).
+		Delete(&model.RelationTuple{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete relation tuple")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("relation tuple not found")
+	}
+	return nil
+}
+
+func (s *AppService) CheckRelation(ctx context.Context, req *schema.CheckRelation) (bool, error) {
+	return s.Checker().Check(ctx, req.Namespace, req.ObjectID, req.Relation, rebac.Subject{
+		Type: req.SubjectType,
+		ID:   req.SubjectID,
+	})
+}
+
+func (s *AppService) ExpandRelation(ctx context.Context, req *schema.ExpandRelation) (*rebac.Node, error) {
+	return s.Checker().Expand(ctx, req.Namespace, req.ObjectID, req.Relation)
+}
+
+func (s *AppService) ListObjects(ctx context.Context, req *schema.ListObjects) ([]string, error) {
+	return s.Checker().ListObjects(ctx, req.Namespace, req.Relation, rebac.Subject{
+		Type: req.SubjectType,
+		ID:   req.SubjectID,
+	})
+}
@@ -2,9 +2,12 @@ package service
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/permission"
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +16,7 @@ func (s *AppService) Roles(ids ...string) ([]model.Role, error) {
 	if len(ids) == 0 {
 		if err := s.DB.
 			Preload("Permissions").
+			Preload("Grants").
 			Find(&data).Error; err != nil {
 			return nil, fmt.Errorf("failed to query database")
 		}
@@ -20,6 +24,7 @@ func (s *AppService) Roles(ids ...string) ([]model.Role, error) {
 	}
 	if err := s.DB.
 		Preload("Permissions").
+		Preload("Grants").
 		Where("id IN ?", ids).
 		Find(&data).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch roles")
@@ -27,27 +32,45 @@ func (s *AppService) Roles(ids ...string) ([]model.Role, error) {
 	return data, nil
 }
 
-func (s *AppService) CreateRole(req *schema.CreateRole) (*model.Role, error) {
+// CreateRole creates the role on behalf of editor, who may only allow the
+// permissions they hold.
+func (s *AppService) CreateRole(req *schema.CreateRole, editor *secret.JwtClaims) (*model.Role, error) {
 	var role model.Role
-	if len(req.Permissions) > 0 {
-		permissions, err := s.Permissions(req.Permissions...)
-		if err != nil {
-			return nil, fmt.Errorf("permission with ids does not exist")
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		ids := mergeIDs(req.Permissions, req.DeniedPermissions)
+		if len(ids) > 0 {
+			var permissions []model.Permission
+			if err := tx.
+				Where("id IN ?", ids).
+				Find(&permissions).Error; err != nil {
+				return fmt.Errorf("permission with ids does not exist")
+			}
+			role.Permissions = permissions
 		}
-		role.Permissions = permissions
-	}
 
-	role.Name = req.Name
-	role.Description = req.Description
-	role.Active = true
+		role.Name = req.Name
+		role.Description = req.Description
+		role.Active = true
+		role.ManagedBy = model.ManagedByUser
+
+		if err := guardGrant(editor, requestedCodes(role.Permissions, req.DeniedPermissions), nil); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&role).Error; err != nil {
+			return fmt.Errorf("failed to create role")
+		}
 
-	if err := s.DB.Create(&role).Error; err != nil {
-		return nil, fmt.Errorf("failed to create role")
+		return setRoleEffects(tx, &role, req.DeniedPermissions)
+	}); err != nil {
+		return nil, s.auditRejection(editor, "create_role", "role", req.Name, err)
 	}
 	return &role, nil
 }
 
-func (s *AppService) UpdateRole(req *schema.UpdateRole) (*model.Role, error) {
+// UpdateRole updates the role on behalf of editor, who may only allow the
+// permissions they hold. Permissions the role already allowed are kept.
+func (s *AppService) UpdateRole(req *schema.UpdateRole, editor *secret.JwtClaims) (*model.Role, error) {
 	var role model.Role
 
 	if err := s.DB.Transaction(func(tx *gorm.DB) error {
@@ -64,10 +87,11 @@ func (s *AppService) UpdateRole(req *schema.UpdateRole) (*model.Role, error) {
 		role.Description = req.Description
 		role.Active = req.Active
 
-		if len(req.Permissions) > 0 {
+		ids := mergeIDs(req.Permissions, req.DeniedPermissions)
+		if len(ids) > 0 {
 			var permissions []model.Permission
 			if err := tx.
-				Where("id IN ?", req.Permissions).
+				Where("id IN ?", ids).
 				Find(&permissions).Error; err != nil {
 				return fmt.Errorf("failed to fetch permissions")
 			}
@@ -76,18 +100,84 @@ func (s *AppService) UpdateRole(req *schema.UpdateRole) (*model.Role, error) {
 			role.Permissions = nil
 		}
 
-		if err := tx.Model(&role).Select("*").Updates(role).Error; err != nil {
+		before := allowedCodes(&roles[0])
+		var gained []string
+		for _, code := range requestedCodes(role.Permissions, req.DeniedPermissions) {
+			if !slices.Contains(before, code) {
+				gained = append(gained, code)
+			}
+		}
+		if err := guardGrant(editor, gained, nil); err != nil {
+			return err
+		}
+		stillAdmin := role.Active && slices.Contains(requestedCodes(role.Permissions, req.DeniedPermissions), string(permission.PermissionAdmin))
+		if err := guardAdminRole(tx, &roles[0], stillAdmin); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&role).Omit("Permissions", "Grants").Select("*").Updates(role).Error; err != nil {
 			return fmt.Errorf("failed to update role: %w", err)
 		}
+		if roles[0].Name != role.Name {
+			if err := s.enforceSodForRole(tx, req.ID); err != nil {
+				return err
+			}
+		}
 
 		if err := tx.Model(&role).Association("Permissions").Replace(role.Permissions); err != nil {
 			return fmt.Errorf("failed to update roles: %w", err)
 		}
 
-		return nil
+		return setRoleEffects(tx, &role, req.DeniedPermissions)
 	}); err != nil {
-		return nil, err
+		return nil, s.auditRejection(editor, "update_role", "role", req.ID, err)
 	}
 
+	s.Decisions.Purge()
 	return &role, nil
 }
+
+// setRoleEffects marks the links of the role to the denied permission ids as
+// deny and every other link as allow, then reloads the role grants.
+func setRoleEffects(tx *gorm.DB, role *model.Role, denied []string) error {
+	if err := tx.Model(&model.RolePermission{}).
+		Where("role_id = ?", role.ID).
+		Update("effect", model.EffectAllow).Error; err != nil {
+		return fmt.Errorf("failed to update role grants: %w", err)
+	}
+	if len(denied) > 0 {
+		if err := tx.Model(&model.RolePermission{}).
+			Where("role_id = ? AND permission_id IN ?", role.ID, denied).
+			Update("effect", model.EffectDeny).Error; err != nil {
+			return fmt.Errorf("failed to update role grants: %w", err)
+		}
+	}
+	if err := tx.Where("role_id = ?", role.ID).Find(&role.Grants).Error; err != nil {
+		return fmt.Errorf("failed to fetch role grants: %w", err)
+	}
+	return nil
+}
+
+// requestedCodes returns the codes of the permissions a role request allows,
+// that is every linked permission not listed as denied.
+func requestedCodes(permissions []model.Permission, denied []string) []string {
+	var codes []string
+	for _, p := range permissions {
+		if !slices.Contains(denied, p.ID.String()) {
+			codes = append(codes, p.Code)
+		}
+	}
+	return codes
+}
+
+func mergeIDs(lists ...[]string) []string {
+	var ids []string
+	for _, list := range lists {
+		for _, id := range list {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/sod"
+	"gorm.io/gorm"
+)
+
+// SodViolation is a separation-of-duties rule broken by a user.
+type SodViolation struct {
+	User model.User
+	sod.Violation
+}
+
+func roleNames(roles []model.Role) []string {
+	var names []string
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// enforceSod returns a sod.ErrConflict error when a user holding the roles
+// would break a separation-of-duties rule. Every assigned role counts, even
+// inactive or scheduled ones.
+func (s *AppService) enforceSod(roles []model.Role) error {
+	return sod.Enforce(s.SeparationOfDuties, roleNames(roles))
+}
+
+// enforceSodForRole checks every holder of the role, e.g. after it was
+// renamed into one of the rules.
+func (s *AppService) enforceSodForRole(tx *gorm.DB, roleID string) error {
+	if len(s.SeparationOfDuties) == 0 {
+		return nil
+	}
+	var ids []string
+	if err := tx.Table("users_roles").Where("role_id = ?", roleID).Pluck("user_id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to fetch role holders")
+	}
+	var groupIDs []string
+	if err := tx.Table("groups_roles").Where("role_id = ?", roleID).Pluck("group_id", &groupIDs).Error; err != nil {
+		return fmt.Errorf("failed to fetch role holders")
+	}
+	var members []string
+	if len(groupIDs) > 0 {
+		if err := tx.Table("groups_users").Where("group_id IN ?", groupIDs).Pluck("user_id", &members).Error; err != nil {
+			return fmt.Errorf("failed to fetch role holders")
+		}
+	}
+	return s.enforceSodForUsers(tx, mergeIDs(ids, members))
+}
+
+// enforceSodForUsers checks the direct and group roles of the users as they
+// are stored in tx.
+func (s *AppService) enforceSodForUsers(tx *gorm.DB, ids []string) error {
+	if len(s.SeparationOfDuties) == 0 || len(ids) == 0 {
+		return nil
+	}
+	var users []model.User
+	if err := tx.
+		Preload("Roles").
+		Preload("Groups.Roles").
+		Where("id IN ?", ids).
+		Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to fetch users")
+	}
+	for _, user := range users {
+		if err := s.enforceSod(user.AllRoles()); err != nil {
+			return fmt.Errorf("user %s: %w", user.Username, err)
+		}
+	}
+	return nil
+}
+
+// SodViolations reports the separation-of-duties rules broken by the roles
+// users currently hold.
+func (s *AppService) SodViolations() ([]SodViolation, error) {
+	if len(s.SeparationOfDuties) == 0 {
+		return nil, nil
+	}
+	users, err := s.Users()
+	if err != nil {
+		return nil, err
+	}
+	var data []SodViolation
+	for _, user := range users {
+		for _, violation := range sod.Check(s.SeparationOfDuties, roleNames(user.AllRoles())) {
+			data = append(data, SodViolation{User: user, Violation: violation})
+		}
+	}
+	return data, nil
+}
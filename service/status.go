@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTransition is wrapped by the errors of status changes that
+// aren't an edge of the account lifecycle.
+var ErrInvalidTransition = errors.New("invalid account status transition")
+
+const defaultStatusTTL = 30 * time.Second
+
+// statusActions are the audit actions of the transitions to each status.
+var statusActions = map[model.UserStatus]string{
+	model.UserActive:        "activate_user",
+	model.UserSuspended:     "suspend_user",
+	model.UserLocked:        "lock_user",
+	model.UserDeprovisioned: "deprovision_user",
+}
+
+// StatusCache keeps the account errors of users for a short time, so that
+// protected routes don't hit the database on every request.
+type StatusCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedStatus
+}
+
+type cachedStatus struct {
+	err     error
+	expires time.Time
+}
+
+func NewStatusCache(ttl time.Duration) *StatusCache {
+	if ttl <= 0 {
+		ttl = defaultStatusTTL
+	}
+	return &StatusCache{ttl: ttl, entries: map[string]cachedStatus{}}
+}
+
+func (c *StatusCache) Get(userID string) (error, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, userID)
+		return nil, false
+	}
+	return entry.err, true
+}
+
+func (c *StatusCache) Set(userID string, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[userID] = cachedStatus{err: err, expires: time.Now().Add(c.ttl)}
+}
+
+// Forget drops the cached status of the user. It is called whenever the
+// status changes.
+func (c *StatusCache) Forget(userID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+}
+
+// AccountStatus returns the account error of the user as of now, as a
+// secret.AccountChecker. Other instances of the application see status
+// changes once their cache expires.
+func (s *AppService) AccountStatus(userID string) error {
+	if err, ok := s.Statuses.Get(userID); ok {
+		return err
+	}
+	var user model.User
+	if err := s.DB.Select("id", "status", "suspended_until").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("id user not found")
+			s.Statuses.Set(userID, err)
+			return err
+		}
+		return fmt.Errorf("failed to query database")
+	}
+	err := user.AccountError(time.Now())
+	s.Statuses.Set(userID, err)
+	return err
+}
+
+// ChangeUserStatus moves the user along an edge of the account lifecycle
+// on behalf of editor, who can't change their own status.
+func (s *AppService) ChangeUserStatus(editor *secret.JwtClaims, req *schema.ChangeUserStatus) (*model.User, error) {
+	if editor != nil && editor.ID == req.ID {
+		return nil, fmt.Errorf("you can't change the status of your own account")
+	}
+	status := model.UserStatus(req.Status)
+	if req.Until != nil && (status != model.UserSuspended || !req.Until.After(time.Now())) {
+		return nil, fmt.Errorf("until must be in the future and only applies to suspensions")
+	}
+	var user model.User
+	var lapsed *model.User
+	var from model.UserStatus
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		users, err := s.Users(req.ID)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("no users found")
+		}
+		user = users[0]
+		// A suspension past its end is ended first, so that the user can
+		// be suspended again or locked from active.
+		if status != model.UserActive && user.Status == model.UserSuspended && user.StatusAt(time.Now()) == model.UserActive {
+			if err := s.setStatus(tx, &user, model.UserActive, "", nil); err != nil {
+				return err
+			}
+			ended := user
+			lapsed = &ended
+		}
+		from = user.Status
+		return s.setStatus(tx, &user, status, req.Reason, req.Until)
+	}); err != nil {
+		return nil, err
+	}
+
+	var actorID string
+	if editor != nil {
+		actorID = editor.ID
+	}
+	if lapsed != nil {
+		s.auditStatus("", lapsed, model.UserSuspended, "suspension ended")
+	}
+	s.auditStatus(actorID, &user, from, req.Reason)
+	s.Decisions.Purge()
+	return &user, nil
+}
+
+// setStatus moves the user from its stored status to status, keeping
+// Active in line with it and recording the reason and end of a suspension.
+// The change revokes the refresh tokens of the user.
+func (s *AppService) setStatus(tx *gorm.DB, user *model.User, status model.UserStatus, reason string, until *time.Time) error {
+	if !user.Status.CanTransition(status) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, user.Status, status)
+	}
+	if status != model.UserActive {
+		if err := guardLastSuperUser(tx, user, false, user.IsSuperUser); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	user.Status, user.Active, user.StatusChangedAt = status, status == model.UserActive, &now
+	user.SuspendedReason, user.SuspendedUntil = "", nil
+	if status == model.UserSuspended {
+		user.SuspendedReason, user.SuspendedUntil = reason, until
+	}
+	if err := tx.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]any{
+		"status":            user.Status,
+		"active":            user.Active,
+		"suspended_reason":  user.SuspendedReason,
+		"suspended_until":   user.SuspendedUntil,
+		"status_changed_at": now,
+		"updated_at":        now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	s.Statuses.Forget(user.ID.String())
+	return nil
+}
+
+// auditStatus records a transition of the user from the status from.
+func (s *AppService) auditStatus(actorID string, user *model.User, from model.UserStatus, reason string) {
+	details := map[string]any{"from": string(from), "to": string(user.Status)}
+	if user.SuspendedUntil != nil {
+		details["until"] = user.SuspendedUntil.Format("02/01/2006 15:04:05")
+	}
+	s.audit(model.AuditEvent{
+		ActorID:    actorID,
+		Action:     statusActions[user.Status],
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Outcome:    model.AuditOutcomeSuccess,
+		Reason:     reason,
+		Details:    details,
+	})
+}
+
+// endSuspension reactivates a user whose suspension ended. It only runs
+// when the user logs in, so a failure is logged and the login goes on.
+func (s *AppService) endSuspension(user *model.User) {
+	from := user.Status
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.setStatus(tx, user, model.UserActive, "", nil)
+	}); err != nil {
+		s.Logger.Error("failed to end suspension", "error", err, "user_id", user.ID.String())
+		return
+	}
+	s.auditStatus("", user, from, "suspension ended")
+	s.Decisions.Purge()
+}
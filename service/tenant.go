@@ -112,5 +112,7 @@ func (s *AppService) UpdateTenant(ctx *fiber.Ctx, req *schema.UpdateTenant) (*mo
 	}); err != nil {
 		return nil, err
 	}
+
+	s.Decisions.Purge()
 	return &data, nil
 }
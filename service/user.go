@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"mime/multipart"
 	"time"
 
 	"github.com/go-gorote/auth/model"
+	"github.com/go-gorote/auth/passwd"
 	"github.com/go-gorote/auth/schema"
+	"github.com/go-gorote/auth/secret"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -16,7 +19,13 @@ func (s *AppService) Users(ids ...string) ([]model.User, error) {
 	if len(ids) == 0 {
 		if err := s.DB.
 			Preload("Roles.Permissions").
+			Preload("Roles.Grants").
 			Preload("Tenants").
+			Preload("RoleAssignments").
+			Preload("TenantAssignments").
+			Preload("Groups.Roles.Permissions").
+			Preload("Groups.Roles.Grants").
+			Preload("Groups.Tenants").
 			Find(&data).Error; err != nil {
 			return nil, fmt.Errorf("failed to query database list")
 		}
@@ -25,7 +34,13 @@ func (s *AppService) Users(ids ...string) ([]model.User, error) {
 
 	if err := s.DB.
 		Preload("Roles.Permissions").
+		Preload("Roles.Grants").
 		Preload("Tenants").
+		Preload("RoleAssignments").
+		Preload("TenantAssignments").
+		Preload("Groups.Roles.Permissions").
+		Preload("Groups.Roles.Grants").
+		Preload("Groups.Tenants").
 		Where("id IN ?", ids).
 		Find(&data).Error; err != nil {
 		return nil, fmt.Errorf("failed to query database")
@@ -33,75 +48,207 @@ func (s *AppService) Users(ids ...string) ([]model.User, error) {
 	return data, nil
 }
 
-func (s *AppService) CreateUser(ctx *fiber.Ctx, req *schema.CreateUser, passwordHash string, editorSuper bool) (*model.User, error) {
+// CreateUser creates the user on behalf of editor, who may only grant
+// roles and tenants within their own access. A nil editor is the system.
+// When req asks for a generated password, it is returned so that it can be
+// handed to the user, who must change it at their first login.
+func (s *AppService) CreateUser(ctx *fiber.Ctx, req *schema.CreateUser, editor *secret.JwtClaims) (*model.User, string, error) {
 	var user model.User
+	var temporaryPassword string
+	if err := validateAssignments(req.RoleAssignments, req.TenantAssignments); err != nil {
+		return nil, "", err
+	}
 	if err := s.DB.Transaction(func(tx *gorm.DB) error {
-		c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		user.Email = req.Email
-		user.Username = req.Username
-		user.Password = passwordHash
+		user.Email = model.NormalizeEmail(req.Email)
+		user.Username = model.NormalizeUsername(req.Username)
 		user.FirstName = req.FirstName
 		user.LastName = req.LastName
-		user.Active = req.Active
-		if editorSuper {
+		user.Status = model.UserActive
+		if !req.Active {
+			user.Status = model.UserPending
+		}
+		if editor == nil || editor.IsSuperUser {
 			user.IsSuperUser = req.IsSuperUser
 		}
 		user.Phone1 = req.Phone1
 		user.Phone2 = req.Phone2
 
 		if req.Avatar != nil {
-			if req.Avatar.Size >= 10*1024 {
-				return fmt.Errorf("logo size must be less than 1MB")
-			}
-			user.Avatar = fmt.Sprintf("uploads-%s/avatar%v%s", s.AppName, time.Now().UnixMicro(), req.Avatar.Filename)
-
-			if s.Storage == nil {
-				filePath := fmt.Sprintf("./%s", user.Avatar)
-				if err := ctx.SaveFile(req.Avatar, filePath); err != nil {
-					return fmt.Errorf("error saving file")
-				}
-			} else {
-				file, err := req.Avatar.Open()
-				if err != nil {
-					return err
-				}
-				defer file.Close()
-				if err := s.Storage.Upload(c, s.Bucket, user.Avatar, file, req.Avatar.Header.Get("Content-Type")); err != nil {
-					return err
-				}
+			avatar, err := s.saveAvatar(ctx, req.Avatar)
+			if err != nil {
+				return err
 			}
+			user.Avatar = avatar
 		}
 
-		if len(req.Roles) > 0 {
-			roles, err := s.Roles(req.Roles...)
+		if roleIDs := assignmentIDs(req.Roles, req.RoleAssignments); len(roleIDs) > 0 {
+			roles, err := s.Roles(roleIDs...)
 			if err != nil {
 				return err
 			}
 			user.Roles = roles
 		}
-		if len(req.Tenants) > 0 {
-			tenants, err := s.Tenants(req.Tenants...)
+		if tenantIDs := assignmentIDs(req.Tenants, req.TenantAssignments); len(tenantIDs) > 0 {
+			tenants, err := s.Tenants(tenantIDs...)
 			if err != nil {
 				return err
 			}
 			user.Tenants = tenants
 		}
 
+		// The policy depends on the tenants of the user.
+		password := req.Password
+		user.MustChangePassword = req.MustChangePassword
+		if req.GeneratePassword {
+			generated, err := secret.RandomPassword(max(16, s.PasswordPolicyFor(&user).MinLength))
+			if err != nil {
+				return fmt.Errorf("failed to generate password")
+			}
+			password, temporaryPassword = generated, generated
+			user.MustChangePassword = true
+		}
+		if err := s.checkPassword(tx, &user, password); err != nil {
+			return err
+		}
+		hash, err := s.hashPassword(password)
+		if err != nil {
+			return fmt.Errorf("crypting password failed")
+		}
+		now := time.Now()
+		user.Password, user.PasswordChangedAt = hash, &now
+
+		if err := guardRoles(editor, user.Roles, user.Tenants); err != nil {
+			return err
+		}
+		if err := s.enforceSod(user.Roles); err != nil {
+			return err
+		}
+
 		if err := tx.Create(&user).Error; err != nil {
 			return fmt.Errorf("failed to create user")
 		}
+		if err := setAssignments(tx, &user, req.RoleAssignments, req.TenantAssignments); err != nil {
+			return err
+		}
 
 		return nil
 	}); err != nil {
-		return nil, err
+		return nil, "", s.auditRejection(editor, "create_user", "user", req.Username, err)
 	}
 
-	return &user, nil
+	return &user, temporaryPassword, nil
 }
 
-func (s *AppService) UpdateUser(req *schema.UpdateUser, editorSuper, editorPermission bool) (*model.User, error) {
+// ImportUsers creates the users of another system with the password hashes
+// it stored, all or none of them. The hashes are parsed with passwd.Check
+// but not checked against the password policy, and are replaced by the
+// configured hasher at each user's first login.
+func (s *AppService) ImportUsers(req *schema.ImportUsers, editor *secret.JwtClaims) ([]model.User, error) {
+	hashers := s.passwordHashers()
+	users := make([]model.User, len(req.Users))
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for i, imported := range req.Users {
+			// Verifying a malformed or hostile hash would fail or stall the
+			// user's logins.
+			if err := passwd.Check(imported.PasswordHash, hashers...); err != nil {
+				return fmt.Errorf("user %s (row %d): %w", imported.Username, i+1, err)
+			}
+			user := &users[i]
+			user.Email = model.NormalizeEmail(imported.Email)
+			user.Username = model.NormalizeUsername(imported.Username)
+			user.FirstName = imported.FirstName
+			user.LastName = imported.LastName
+			user.Status = model.UserActive
+			if !imported.Active {
+				user.Status = model.UserPending
+			}
+			user.Phone1 = imported.Phone1
+			user.Phone2 = imported.Phone2
+			user.Password = imported.PasswordHash
+			user.PasswordChangedAt = imported.PasswordChangedAt
+
+			if len(imported.Roles) > 0 {
+				roles, err := s.Roles(imported.Roles...)
+				if err != nil {
+					return err
+				}
+				user.Roles = roles
+			}
+			if len(imported.Tenants) > 0 {
+				tenants, err := s.Tenants(imported.Tenants...)
+				if err != nil {
+					return err
+				}
+				user.Tenants = tenants
+			}
+			if err := guardRoles(editor, user.Roles, user.Tenants); err != nil {
+				return err
+			}
+			if err := s.enforceSod(user.Roles); err != nil {
+				return err
+			}
+
+			if err := tx.Create(user).Error; err != nil {
+				return fmt.Errorf("failed to import user %s", imported.Username)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, s.auditRejection(editor, "import_users", "user", "", err)
+	}
+
+	var actorID string
+	if editor != nil {
+		actorID = editor.ID
+	}
+	s.audit(model.AuditEvent{
+		ActorID:    actorID,
+		Action:     "import_users",
+		TargetType: "user",
+		Outcome:    model.AuditOutcomeSuccess,
+		Details:    map[string]any{"total": len(users)},
+	})
+	return users, nil
+}
+
+// saveAvatar stores the uploaded avatar in the configured storage, or under
+// ./uploads without one, and returns its path.
+func (s *AppService) saveAvatar(ctx *fiber.Ctx, avatar *multipart.FileHeader) (string, error) {
+	if avatar.Size >= 10*1024 {
+		return "", fmt.Errorf("logo size must be less than 1MB")
+	}
+	path := fmt.Sprintf("uploads-%s/avatar%v%s", s.AppName, time.Now().UnixMicro(), avatar.Filename)
+
+	if s.Storage == nil {
+		filePath := fmt.Sprintf("./%s", path)
+		if err := ctx.SaveFile(avatar, filePath); err != nil {
+			return "", fmt.Errorf("error saving file")
+		}
+		return path, nil
+	}
+	c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	file, err := avatar.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := s.Storage.Upload(c, s.Bucket, path, file, avatar.Header.Get("Content-Type")); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// UpdateUser updates the user on behalf of editor. Roles and tenants are
+// only changed when editorPermission is set, and the ones added must be
+// within the editor's own access.
+func (s *AppService) UpdateUser(req *schema.UpdateUser, editor *secret.JwtClaims, editorPermission bool) (*model.User, error) {
+	editorSuper := editor == nil || editor.IsSuperUser
 	var user model.User
+	var from model.UserStatus
+	if err := validateAssignments(req.RoleAssignments, req.TenantAssignments); err != nil {
+		return nil, err
+	}
 	if err := s.DB.Transaction(func(tx *gorm.DB) error {
 		users, err := s.Users(req.ID)
 		if err != nil {
@@ -111,27 +258,49 @@ func (s *AppService) UpdateUser(req *schema.UpdateUser, editorSuper, editorPermi
 			return fmt.Errorf("no users found")
 		}
 		user = users[0]
+		isSuper := user.IsSuperUser
+		if editorSuper {
+			isSuper = req.IsSuperUser
+		}
+		if err := guardLastSuperUser(tx, &users[0], req.Active, isSuper); err != nil {
+			return err
+		}
 
-		user.Email = req.Email
-		user.Username = req.Username
+		user.Email = model.NormalizeEmail(req.Email)
+		user.Username = model.NormalizeUsername(req.Username)
 		user.FirstName = req.FirstName
 		user.LastName = req.LastName
-		user.Active = req.Active
 		user.Phone1 = req.Phone1
 		user.Phone2 = req.Phone2
 		if editorSuper {
 			user.IsSuperUser = req.IsSuperUser
 		}
-		if err := tx.Model(&user).Select("*").Updates(user).Error; err != nil {
+		if err := tx.Model(&user).Omit("Groups", "RoleAssignments", "TenantAssignments").Select("*").Updates(user).Error; err != nil {
 			return fmt.Errorf("failed to update user: %w", err)
 		}
+		// Active is kept for older clients: turning it off suspends the
+		// user and turning it on activates them. It is compared with the
+		// stored status, which the clients echo, so that a lapsed
+		// suspension isn't suspended again.
+		if req.Active != (user.Status == model.UserActive) {
+			status := model.UserSuspended
+			if req.Active {
+				status = model.UserActive
+			}
+			from = user.Status
+			if err := s.setStatus(tx, &user, status, "", nil); err != nil {
+				return err
+			}
+		}
 
 		if editorPermission || editorSuper {
-			if len(req.Roles) > 0 {
+			before, beforeTenants := user.Roles, user.Tenants
+			if roleIDs := assignmentIDs(req.Roles, req.RoleAssignments); len(roleIDs) > 0 {
 				var roles []model.Role
 				if err := tx.
 					Preload("Permissions").
-					Where("id IN ?", req.Roles).
+					Preload("Grants").
+					Where("id IN ?", roleIDs).
 					Find(&roles).Error; err != nil {
 					return fmt.Errorf("failed to fetch roles")
 				}
@@ -139,11 +308,14 @@ func (s *AppService) UpdateUser(req *schema.UpdateUser, editorSuper, editorPermi
 			} else {
 				user.Roles = nil
 			}
+			if err := s.enforceSod(user.AllRoles()); err != nil {
+				return err
+			}
 
-			if len(req.Tenants) > 0 {
+			if tenantIDs := assignmentIDs(req.Tenants, req.TenantAssignments); len(tenantIDs) > 0 {
 				var tenants []model.Tenant
 				if err := tx.
-					Where("id IN ?", req.Tenants).
+					Where("id IN ?", tenantIDs).
 					Find(&tenants).Error; err != nil {
 					return fmt.Errorf("failed to fetch tenants")
 				}
@@ -151,6 +323,9 @@ func (s *AppService) UpdateUser(req *schema.UpdateUser, editorSuper, editorPermi
 			} else {
 				user.Tenants = nil
 			}
+			if err := guardRoles(editor, added(before, user.Roles, roleID), added(beforeTenants, user.Tenants, tenantID)); err != nil {
+				return err
+			}
 		}
 		if err := tx.Model(&user).Association("Roles").Replace(user.Roles); err != nil {
 			return fmt.Errorf("failed to update roles: %w", err)
@@ -158,11 +333,55 @@ func (s *AppService) UpdateUser(req *schema.UpdateUser, editorSuper, editorPermi
 		if err := tx.Model(&user).Association("Tenants").Replace(user.Tenants); err != nil {
 			return fmt.Errorf("failed to update tenants: %w", err)
 		}
+		if editorPermission || editorSuper {
+			if err := setAssignments(tx, &user, req.RoleAssignments, req.TenantAssignments); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	}); err != nil {
-		return nil, err
+		return nil, s.auditRejection(editor, "update_user", "user", req.ID, err)
+	}
+	if from != "" {
+		var actorID string
+		if editor != nil {
+			actorID = editor.ID
+		}
+		s.auditStatus(actorID, &user, from, "")
 	}
 
+	s.Decisions.Purge()
 	return &user, nil
 }
+
+// PermissionGrants returns the user with every path through which they hold
+// a permission at t. Super users additionally hold every active permission.
+func (s *AppService) PermissionGrants(userID string, t time.Time) (*model.User, []model.PermissionGrant, error) {
+	users, err := s.Users(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil, fmt.Errorf("id user not found")
+	}
+	user := users[0]
+	grants := user.PermissionGrants(t)
+	if user.IsSuperUser {
+		var codes []string
+		if err := s.DB.Model(&model.Permission{}).
+			Where("active = ?", true).
+			Order("code").
+			Pluck("code", &codes).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch permissions")
+		}
+		for _, code := range codes {
+			grants = append(grants, model.PermissionGrant{
+				Code:   code,
+				Effect: model.EffectAllow,
+				Source: model.SourceSuperUser,
+			})
+		}
+	}
+	return &user, grants, nil
+}
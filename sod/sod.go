@@ -0,0 +1,80 @@
+// Package sod checks static separation-of-duties rules: sets of roles that no
+// single user may hold together.
+package sod
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrConflict is wrapped by every error reporting a violation, so callers
+// can tell it apart with errors.Is.
+var ErrConflict = errors.New("separation of duties violation")
+
+// Rule declares mutually exclusive roles by name. A user may hold at most
+// one of them.
+type Rule struct {
+	Name  string
+	Roles []string
+}
+
+type Violation struct {
+	Rule  string
+	Roles []string
+}
+
+// Error lists the violations found for a user.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	var parts []string
+	for _, v := range e.Violations {
+		parts = append(parts, fmt.Sprintf("%s (%s)", v.Rule, strings.Join(v.Roles, ", ")))
+	}
+	return fmt.Sprintf("%s: %s", ErrConflict, strings.Join(parts, "; "))
+}
+
+func (e *Error) Unwrap() error {
+	return ErrConflict
+}
+
+func Validate(rules []Rule) error {
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("separation of duties rule without a name")
+		}
+		if len(rule.Roles) < 2 {
+			return fmt.Errorf("separation of duties rule %q needs at least two roles", rule.Name)
+		}
+	}
+	return nil
+}
+
+// Check returns the rules broken by holding the given roles.
+func Check(rules []Rule, roles []string) []Violation {
+	var violations []Violation
+	for _, rule := range rules {
+		var held []string
+		for _, role := range rule.Roles {
+			if slices.Contains(roles, role) {
+				held = append(held, role)
+			}
+		}
+		if len(held) > 1 {
+			violations = append(violations, Violation{Rule: rule.Name, Roles: held})
+		}
+	}
+	return violations
+}
+
+// Enforce returns an *Error when holding the roles breaks any rule.
+func Enforce(rules []Rule, roles []string) error {
+	if violations := Check(rules, roles); len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
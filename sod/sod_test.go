@@ -0,0 +1,66 @@
+package sod
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var rules = []Rule{
+	{Name: "payments", Roles: []string{"payer", "approver", "auditor"}},
+	{Name: "accounts", Roles: []string{"creator", "approver"}},
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		want  []Violation
+	}{
+		{"no roles", nil, nil},
+		{"one role of each rule", []string{"payer", "creator", "viewer"}, nil},
+		{"two roles of a rule", []string{"payer", "auditor"}, []Violation{
+			{Rule: "payments", Roles: []string{"payer", "auditor"}},
+		}},
+		{"a role shared by two rules", []string{"approver", "payer", "creator"}, []Violation{
+			{Rule: "payments", Roles: []string{"payer", "approver"}},
+			{Rule: "accounts", Roles: []string{"creator", "approver"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(rules, tt.roles)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+			err := Enforce(rules, tt.roles)
+			if (err != nil) != (len(tt.want) > 0) {
+				t.Fatalf("Enforce() error = %v, want %d violations", err, len(tt.want))
+			}
+			var sodErr *Error
+			if err != nil && (!errors.Is(err, ErrConflict) || !errors.As(err, &sodErr) || !reflect.DeepEqual(sodErr.Violations, tt.want)) {
+				t.Errorf("Enforce() error = %v, want an *Error wrapping ErrConflict", err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr bool
+	}{
+		{"valid", rules, false},
+		{"none", nil, false},
+		{"no name", []Rule{{Roles: []string{"a", "b"}}}, true},
+		{"one role", []Rule{{Name: "r", Roles: []string{"a"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}